@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/g00dv1n/pgpanel/core"
+)
+
+// ?table=public.users&row={"id":5}&admin=john&operation=update&from=...&to=...&offset=0&limit=50
+func getAuditLogHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		params, err := core.ParseAuditLogParamsFromQuery(r.URL.Query())
+		if err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+
+		entries, err := app.AuditService.GetAuditLog(params)
+
+		if err != nil {
+			return NewApiError(http.StatusInternalServerError, err)
+		}
+
+		return WriteJson(w, entries)
+	}
+}
+
+func parseAuditEntryID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, NewApiError(http.StatusBadRequest, errors.New("invalid audit entry id"))
+	}
+
+	return id, nil
+}
+
+// diff between the current row and the row after revert
+func previewRevertHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id, err := parseAuditEntryID(r)
+		if err != nil {
+			return err
+		}
+
+		preview, err := app.DataService.PreviewRevert(id)
+
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		return WriteJson(w, preview)
+	}
+}
+
+func revertChangeHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id, err := parseAuditEntryID(r)
+		if err != nil {
+			return err
+		}
+
+		rows, err := app.DataService.WithAdmin(requestAdmin(r)).RevertChange(id)
+
+		var conflictErr *core.RevertConflictError
+		if errors.As(err, &conflictErr) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+
+			return json.NewEncoder(w).Encode(map[string]any{
+				"code":    http.StatusConflict,
+				"message": conflictErr.Error(),
+				"preview": conflictErr.Preview,
+			})
+		}
+
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		return WriteJson(w, rows)
+	}
+}
@@ -3,7 +3,10 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/g00dv1n/pgpanel/core"
 )
@@ -11,7 +14,10 @@ import (
 func getRowsHandler(app *core.App) ApiHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		tableName := r.PathValue("table")
-		params := core.ParseGetRowsParamsFromQuery(r.URL.Query())
+		params, err := core.ParseGetRowsParamsFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
 
 		rows, err := app.DataService.GetRows(tableName, params)
 
@@ -23,10 +29,63 @@ func getRowsHandler(app *core.App) ApiHandler {
 	}
 }
 
+func exportRowsHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tableName := r.PathValue("table")
+		params, err := core.ParseGetRowsParamsFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		format, err := core.ParseExportFormat(r.URL.Query().Get("format"))
+		if err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+
+		if _, err := app.SchemaService.GetTable(tableName); err != nil {
+			return mapDataError(err)
+		}
+
+		aw := &attachmentWriter{
+			ResponseWriter: w,
+			contentType:    format.ContentType(),
+			filename:       fmt.Sprintf("%s.%s", tableName, format),
+		}
+
+		err = app.DataService.ExportRows(tableName, params, format, aw)
+		if err != nil && !aw.written {
+			return mapDataError(err)
+		}
+
+		return err
+	}
+}
+
+// sets download headers on the first write, so errors before it are returned as JSON
+type attachmentWriter struct {
+	http.ResponseWriter
+	contentType string
+	filename    string
+	written     bool
+}
+
+func (aw *attachmentWriter) Write(p []byte) (int, error) {
+	if !aw.written {
+		aw.written = true
+		aw.Header().Set("Content-Type", aw.contentType)
+		aw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, aw.filename))
+	}
+
+	return aw.ResponseWriter.Write(p)
+}
+
 func getTableViewHandler(app *core.App) ApiHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		tableName := r.PathValue("table")
-		params := core.ParseGetRowsParamsFromQuery(r.URL.Query())
+		params, err := core.ParseGetRowsParamsFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
 
 		view, err := app.DataService.GetTableView(tableName, params)
 
@@ -41,16 +100,23 @@ func getTableViewHandler(app *core.App) ApiHandler {
 func getFormViewHandler(app *core.App) ApiHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		tableName := r.PathValue("table")
-		filters := core.ParseFiltersFromQuery(r.URL.Query())
+		filters, err := core.ParseFiltersFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
 		mode := core.ParseFormViewModeFromQuery(r.URL.Query())
 
-		rows, err := app.DataService.GetFormView(tableName, filters, mode)
+		view, err := app.DataService.GetFormView(tableName, filters, mode)
 
 		if err != nil {
 			return mapDataError(err)
 		}
 
-		return WriteJson(w, rows)
+		if view.Version != "" {
+			w.Header().Set("ETag", strconv.Quote(view.Version))
+		}
+
+		return WriteJson(w, view)
 	}
 }
 
@@ -58,12 +124,22 @@ func insertRowHandler(app *core.App) ApiHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		tableName := r.PathValue("table")
 
+		upsert, err := core.ParseUpsertOptionsFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
+
 		var row core.RawRow
 		if err := json.NewDecoder(r.Body).Decode(&row); err != nil {
 			return mapDataError(err)
 		}
 
-		rows, err := app.DataService.InsertRow(tableName, row)
+		var rows json.RawMessage
+		if upsert != nil {
+			rows, err = app.DataService.WithAdmin(requestAdmin(r)).UpsertRow(tableName, row, *upsert)
+		} else {
+			rows, err = app.DataService.WithAdmin(requestAdmin(r)).InsertRow(tableName, row)
+		}
 
 		if err != nil {
 			return mapDataError(err)
@@ -73,17 +149,97 @@ func insertRowHandler(app *core.App) ApiHandler {
 	}
 }
 
+func bulkInsertRowsHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tableName := r.PathValue("table")
+
+		options := core.BulkInsertOptions{
+			AllOrNothing: r.URL.Query().Get("allOrNothing") == "true",
+		}
+
+		result, err := app.DataService.WithAdmin(requestAdmin(r)).BulkInsertRows(tableName, r.Body, options)
+
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		return WriteJson(w, result)
+	}
+}
+
+func importRowsHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tableName := r.PathValue("table")
+
+		r.ParseMultipartForm(maxUploadSize)
+
+		file, handler, err := r.FormFile("file")
+		if err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+		defer file.Close()
+
+		var options core.ImportOptions
+
+		options.Format, err = core.ParseImportFormat(r.FormValue("format"), handler.Filename)
+		if err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+
+		options.OnConflict, err = core.ParseImportConflictAction(r.FormValue("onConflict"))
+		if err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+
+		if mapping := r.FormValue("mapping"); mapping != "" {
+			if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+				return NewApiError(http.StatusBadRequest, err)
+			}
+		}
+
+		options.DryRun = r.FormValue("dryRun") == "true"
+
+		result, err := app.DataService.WithAdmin(requestAdmin(r)).ImportRows(tableName, file, options)
+
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		return WriteJson(w, result)
+	}
+}
+
 func updateRowsHandler(app *core.App) ApiHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		tableName := r.PathValue("table")
-		filters := core.ParseFiltersFromQuery(r.URL.Query())
+		filters, err := core.ParseFiltersFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
 
 		var row core.RawRow
 		if err := json.NewDecoder(r.Body).Decode(&row); err != nil {
 			return mapDataError(err)
 		}
 
-		rows, err := app.DataService.UpdateRows(tableName, filters, row)
+		var rows json.RawMessage
+		if version := parseIfMatch(r); version != "" {
+			rows, err = app.DataService.WithAdmin(requestAdmin(r)).UpdateRowsIfMatch(tableName, filters, row, version)
+		} else {
+			rows, err = app.DataService.WithAdmin(requestAdmin(r)).UpdateRows(tableName, filters, row)
+		}
+
+		var staleErr *core.StaleRowError
+		if errors.As(err, &staleErr) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+
+			return json.NewEncoder(w).Encode(map[string]any{
+				"code":    http.StatusConflict,
+				"message": staleErr.Error(),
+				"current": staleErr.Current,
+			})
+		}
 
 		if err != nil {
 			return mapDataError(err)
@@ -96,9 +252,31 @@ func updateRowsHandler(app *core.App) ApiHandler {
 func deleteRowsHandler(app *core.App) ApiHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		tableName := r.PathValue("table")
-		filters := core.ParseFiltersFromQuery(r.URL.Query())
+		filters, err := core.ParseFiltersFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		rows, err := app.DataService.WithAdmin(requestAdmin(r)).DeleteRows(tableName, filters)
+
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		return WriteJson(w, rows)
+	}
+}
+
+// restores soft-deleted rows matched by filters
+func restoreRowsHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tableName := r.PathValue("table")
+		filters, err := core.ParseFiltersFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
 
-		rows, err := app.DataService.DeleteRows(tableName, filters)
+		rows, err := app.DataService.WithAdmin(requestAdmin(r)).RestoreRows(tableName, filters)
 
 		if err != nil {
 			return mapDataError(err)
@@ -108,6 +286,79 @@ func deleteRowsHandler(app *core.App) ApiHandler {
 	}
 }
 
+// counts rows deleted, nulled or blocking in dependent tables without deleting anything
+func previewDeleteRowsHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tableName := r.PathValue("table")
+		filters, err := core.ParseFiltersFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		preview, err := app.DataService.PreviewDeleteRows(tableName, filters)
+
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		return WriteJson(w, preview)
+	}
+}
+
+func aggregateRowsHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tableName := r.PathValue("table")
+		params, err := core.ParseAggregateParamsFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		rows, err := app.DataService.AggregateRows(tableName, params)
+
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		return WriteJson(w, rows)
+	}
+}
+
+// row is found by filters (e.g. ?where=...), pagination and sorting are applied to child rows
+func getReferencingRowsHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tableName := r.PathValue("table")
+		params, err := core.ParseGetRowsParamsFromQuery(r.URL.Query())
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		referencingTable := r.URL.Query().Get(core.ReferencingTableQK)
+
+		refs, err := app.DataService.GetReferencingRows(tableName, params.Filters, params, referencingTable)
+
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		return WriteJson(w, refs)
+	}
+}
+
+func refreshMaterializedViewHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tableName := r.PathValue("table")
+		concurrently := r.URL.Query().Get("concurrently") == "true"
+
+		refresh, err := app.DataService.WithAdmin(requestAdmin(r)).RefreshMaterializedView(tableName, concurrently)
+
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		return WriteJson(w, refresh)
+	}
+}
+
 // ---------------------- Relations -------------------------------
 
 func parseRelationsConfig(r *http.Request) (*core.RelationsConfig, error) {
@@ -167,7 +418,7 @@ func updateRelatedRowsHandler(app *core.App) ApiHandler {
 			return mapDataError(err)
 		}
 
-		err = app.DataService.UpdateRelatedRows(relationsConf, id, &actions)
+		err = app.DataService.WithAdmin(requestAdmin(r)).UpdateRelatedRows(relationsConf, id, &actions)
 
 		if err != nil {
 			return mapDataError(err)
@@ -177,11 +428,30 @@ func updateRelatedRowsHandler(app *core.App) ApiHandler {
 	}
 }
 
+// extracts row version from If-Match header: "version" or W/"version"
+func parseIfMatch(r *http.Request) string {
+	version := strings.TrimPrefix(r.Header.Get("If-Match"), "W/")
+
+	if unquoted, err := strconv.Unquote(version); err == nil {
+		return unquoted
+	}
+
+	return version
+}
+
 // helper to proccess all CRUD related errors
 func mapDataError(err error) ApiError {
-	if errors.Is(err, core.ErrUnknownTable) {
+	if errors.Is(err, core.ErrUnknownTable) || errors.Is(err, core.ErrRowNotFound) || errors.Is(err, core.ErrNoSuchAuditEntry) {
 		return NewApiError(http.StatusNotFound, err)
 	}
 
+	if errors.Is(err, core.ErrRawSQLFiltersForbidden) {
+		return NewApiError(http.StatusForbidden, err)
+	}
+
+	if errors.Is(err, core.ErrReadOnlyTable) {
+		return NewApiError(http.StatusConflict, err)
+	}
+
 	return NewApiError(http.StatusBadRequest, err)
 }
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/g00dv1n/pgpanel/core"
+)
+
+func mapSavedQueryError(err error) ApiError {
+	if errors.Is(err, core.ErrNoSuchSavedQuery) {
+		return NewApiError(http.StatusNotFound, err)
+	}
+
+	if errors.Is(err, core.ErrSavedQueryNameInUse) {
+		return NewApiError(http.StatusConflict, err)
+	}
+
+	if errors.Is(err, core.ErrInvalidSavedQuery) || errors.Is(err, core.ErrInvalidSavedQueryArg) {
+		return NewApiError(http.StatusBadRequest, err)
+	}
+
+	return NewApiError(http.StatusInternalServerError, err)
+}
+
+func parseSavedQueryID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, NewApiError(http.StatusBadRequest, errors.New("invalid saved query id"))
+	}
+
+	return id, nil
+}
+
+func getSavedQueriesHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		queries, err := app.SavedQueryService.GetSavedQueries()
+
+		if err != nil {
+			return mapSavedQueryError(err)
+		}
+
+		return WriteJson(w, queries)
+	}
+}
+
+func getSavedQueryHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id, err := parseSavedQueryID(r)
+		if err != nil {
+			return err
+		}
+
+		q, err := app.SavedQueryService.GetSavedQuery(id)
+
+		if err != nil {
+			return mapSavedQueryError(err)
+		}
+
+		return WriteJson(w, q)
+	}
+}
+
+func createSavedQueryHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var q core.SavedQuery
+
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+
+		created, err := app.SavedQueryService.CreateSavedQuery(q, requestAdmin(r))
+
+		if err != nil {
+			return mapSavedQueryError(err)
+		}
+
+		return WriteJson(w, created)
+	}
+}
+
+func updateSavedQueryHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id, err := parseSavedQueryID(r)
+		if err != nil {
+			return err
+		}
+
+		var q core.SavedQuery
+
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+
+		updated, err := app.SavedQueryService.UpdateSavedQuery(id, q)
+
+		if err != nil {
+			return mapSavedQueryError(err)
+		}
+
+		return WriteJson(w, updated)
+	}
+}
+
+func deleteSavedQueryHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id, err := parseSavedQueryID(r)
+		if err != nil {
+			return err
+		}
+
+		if err := app.SavedQueryService.DeleteSavedQuery(id); err != nil {
+			return mapSavedQueryError(err)
+		}
+
+		return nil
+	}
+}
+
+// {"params": {"userId": 5}, "readOnly": true}
+func runSavedQueryHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id, err := parseSavedQueryID(r)
+		if err != nil {
+			return err
+		}
+
+		var runReq core.SavedQueryRunRequest
+
+		// numbers are kept as json.Number, so big integers don't lose precision
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&runReq); err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+
+		res, err := app.RunSavedQuery(r.Context(), id, &runReq)
+
+		if errors.Is(err, core.ErrNoSuchSavedQuery) {
+			return NewApiError(http.StatusNotFound, err)
+		}
+
+		if err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+
+		return WriteJson(w, res)
+	}
+}
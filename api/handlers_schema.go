@@ -12,7 +12,12 @@ func getTablesHandler(app *core.App) ApiHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		reload := r.URL.Query().Get("reload") == "true"
 
-		return WriteJson(w, app.SchemaService.GetTablesMap(reload))
+		tables, err := app.SchemaService.GetTablesMap(reload)
+		if err != nil {
+			return NewApiError(http.StatusInternalServerError, err)
+		}
+
+		return WriteJson(w, tables)
 	}
 }
 
@@ -30,6 +35,20 @@ func getTableHandler(app *core.App) ApiHandler {
 	}
 }
 
+func getReferencingTablesHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tableName := r.PathValue("table")
+
+		refs, err := app.SchemaService.GetReferencingTables(tableName)
+
+		if err != nil {
+			return NewApiError(http.StatusNotFound, err)
+		}
+
+		return WriteJson(w, refs)
+	}
+}
+
 func getTableSettingsHandler(app *core.App) ApiHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		tableName := r.PathValue("table")
@@ -57,7 +76,11 @@ func updateTableSettingsHandler(app *core.App) ApiHandler {
 			return NewApiError(http.StatusBadRequest, err)
 		}
 
-		settings, err := app.SchemaService.UpdateTableSettings(tableName, updateSettings)
+		settings, err := app.SchemaService.UpdateTableSettings(tableName, updateSettings, requestAdmin(r))
+
+		if errors.Is(err, core.ErrRawSQLFiltersForbidden) {
+			return NewApiError(http.StatusForbidden, err)
+		}
 
 		if err != nil {
 			return NewApiError(http.StatusBadRequest, err)
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/g00dv1n/pgpanel/core"
@@ -15,7 +16,7 @@ func executeSQLHandler(app *core.App) ApiHandler {
 			return NewApiError(http.StatusBadRequest, err)
 		}
 
-		res, err := app.ExecuteSQL(&sqlReq)
+		res, err := app.ExecuteSQL(r.Context(), &sqlReq)
 		if err != nil {
 			return NewApiError(http.StatusBadRequest, err)
 		}
@@ -23,3 +24,36 @@ func executeSQLHandler(app *core.App) ApiHandler {
 		return WriteJson(w, res)
 	}
 }
+
+func explainSQLHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var explainReq core.SQLExplainRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&explainReq); err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+
+		res, err := app.ExplainSQL(r.Context(), &explainReq)
+		if err != nil {
+			return mapDataError(err)
+		}
+
+		return WriteJson(w, res)
+	}
+}
+
+func cancelSQLHandler(app *core.App) ApiHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := app.SQLService.Cancel(r.PathValue("queryId"))
+
+		if errors.Is(err, core.ErrNoSuchRunningQuery) {
+			return NewApiError(http.StatusNotFound, err)
+		}
+
+		if err != nil {
+			return NewApiError(http.StatusBadRequest, err)
+		}
+
+		return nil
+	}
+}
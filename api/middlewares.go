@@ -28,3 +28,9 @@ func AuthMiddleware(app *core.App) ApiMiddleware {
 		}
 	}
 }
+
+// returns username of the authenticated admin, empty for routes without auth
+func requestAdmin(r *http.Request) string {
+	admin, _ := r.Context().Value(adminContextKey("admin")).(string)
+	return admin
+}
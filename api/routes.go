@@ -24,6 +24,8 @@ var routes = []routeConfig{
 	// Schema API endpoints
 	{"GET /schema/tables", getTablesHandler, authEnabled},
 	{"GET /schema/tables/{table}", getTableHandler, authEnabled},
+	// Foreign keys of other tables pointing at the table
+	{"GET /schema/tables/{table}/references", getReferencingTablesHandler, authEnabled},
 
 	{"GET /schema/table-settings/{table}", getTableSettingsHandler, authEnabled},
 	{"PUT /schema/table-settings/{table}", updateTableSettingsHandler, authEnabled},
@@ -35,12 +37,34 @@ var routes = []routeConfig{
 	{"POST /data/{table}", insertRowHandler, authEnabled},
 	{"PUT /data/{table}", updateRowsHandler, authEnabled},
 	{"DELETE /data/{table}", deleteRowsHandler, authEnabled},
+	// Cascade impact of DELETE with the same filters, nothing is deleted
+	{"GET /data/{table}/delete-preview", previewDeleteRowsHandler, authEnabled},
+	// Restore soft-deleted rows (rows are hidden unless ?withDeleted=true)
+	{"POST /data/{table}/restore", restoreRowsHandler, authEnabled},
+
+	// Import CSV or JSON file with columns mapping (multipart form)
+	{"POST /data/{table}/import", importRowsHandler, authEnabled},
+
+	// Export all rows matched by filters as csv, ndjson or xlsx
+	{"GET /data/{table}/export", exportRowsHandler, authEnabled},
+
+	// Insert many rows at once from JSON array or NDJSON stream
+	{"POST /data/{table}/bulk", bulkInsertRowsHandler, authEnabled},
+
+	// Group rows and calculate aggregates (?groupBy=status&agg=count|sum:amount)
+	{"GET /data/{table}/aggregate", aggregateRowsHandler, authEnabled},
+
+	// Refresh materialized view (?concurrently=true)
+	{"POST /data/{table}/refresh", refreshMaterializedViewHandler, authEnabled},
 
 	// Get all data to render table view with applied table settings
 	{"GET /data/{table}/table-view", getTableViewHandler, authEnabled},
 	// Get all data to render form (row) view
 	{"GET /data/{table}/form-view", getFormViewHandler, authEnabled},
 
+	// Child rows from all tables referencing the row found by filters
+	{"GET /data/{table}/references", getReferencingRowsHandler, authEnabled},
+
 	{"GET /data/{mainTable}/relations/{mainTableRowId}", getRelatedRowsHandler, authEnabled},
 	{"PUT /data/{mainTable}/relations/{mainTableRowId}", updateRelatedRowsHandler, authEnabled},
 
@@ -56,6 +80,24 @@ var routes = []routeConfig{
 
 	// SQL API endpoints
 	{"POST /sql/execute", executeSQLHandler, authEnabled},
+	// EXPLAIN the query or the rows query of the table view
+	{"POST /sql/explain", explainSQLHandler, authEnabled},
+	// Cancel running query by queryId passed to /sql/execute or /sql/explain
+	{"POST /sql/cancel/{queryId}", cancelSQLHandler, authEnabled},
+
+	// Named queries with typed $1..$N params
+	{"GET /sql/saved", getSavedQueriesHandler, authEnabled},
+	{"POST /sql/saved", createSavedQueryHandler, authEnabled},
+	{"GET /sql/saved/{id}", getSavedQueryHandler, authEnabled},
+	{"PUT /sql/saved/{id}", updateSavedQueryHandler, authEnabled},
+	{"DELETE /sql/saved/{id}", deleteSavedQueryHandler, authEnabled},
+	{"POST /sql/saved/{id}/run", runSavedQueryHandler, authEnabled},
+
+	// Changes made through the panel (data and table settings)
+	{"GET /audit", getAuditLogHandler, authEnabled},
+	// Revert a row change: GET returns the diff, POST applies it if the row wasn't changed since
+	{"GET /audit/{id}/revert", previewRevertHandler, authEnabled},
+	{"POST /audit/{id}/revert", revertChangeHandler, authEnabled},
 
 	// Admin API endpoints
 	{"POST /admin/login", adminLoginHandler, authDisabled},
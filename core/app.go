@@ -1,8 +1,10 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -14,11 +16,17 @@ type App struct {
 	Logger        *slog.Logger
 	SchemaService *SchemaService
 	DataService   *DataService
+	SQLService    *SQLService
 
-	AdminService *AdminService
+	AdminService      *AdminService
+	AuditService      *AuditService
+	SavedQueryService *SavedQueryService
 
 	Storage   Storage
 	SecretKey []byte
+
+	// stops background listeners
+	cancel context.CancelFunc
 }
 
 func NewApp(config *Config) *App {
@@ -34,7 +42,7 @@ func NewApp(config *Config) *App {
 	schema, err := NewSchemaService(
 		pool,
 		logger,
-		config.GetSchemaName(),
+		config.GetSchemaNames(),
 		config.IncludedTables,
 	)
 
@@ -45,6 +53,8 @@ func NewApp(config *Config) *App {
 		os.Exit(1)
 	}
 
+	schema.AllowRawSQLSettings = config.AllowRawSQLFilters
+
 	err = schema.CreateAdminTables()
 
 	if err != nil {
@@ -52,7 +62,29 @@ func NewApp(config *Config) *App {
 		os.Exit(1)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if config.SchemaAutoReload {
+		if err := schema.InstallSchemaChangesTrigger(); err != nil {
+			logger.Warn("can't install schema changes trigger, superuser is required", "error", err)
+		}
+
+		go schema.ListenSchemaChanges(ctx)
+	}
+
 	crud := NewDataService(pool, schema, logger)
+	crud.AllowRawSQLFilters = config.AllowRawSQLFilters
+
+	if config.CountEstimateThreshold > 0 {
+		crud.CountEstimateThreshold = config.CountEstimateThreshold
+	}
+
+	sqlService := NewSQLService(pool, logger)
+	sqlService.ForceReadOnly = config.SQLReadOnly
+
+	if config.SQLMaxStatementTimeout > 0 {
+		sqlService.MaxStatementTimeout = config.SQLMaxStatementTimeout
+	}
 
 	localStorage, err := NewLocalStorage(config.UploadDir, config.UploadKeyPattern)
 	if err != nil {
@@ -66,13 +98,18 @@ func NewApp(config *Config) *App {
 	}
 
 	return &App{
-		DB:            pool,
-		Logger:        logger,
-		SchemaService: schema,
-		AdminService:  admin,
-		DataService:   crud,
-		Storage:       localStorage,
-		SecretKey:     secretKey,
+		DB:                pool,
+		Logger:            logger,
+		SchemaService:     schema,
+		AdminService:      admin,
+		AuditService:      NewAuditService(pool, schema, logger),
+		SavedQueryService: NewSavedQueryService(pool, logger),
+		DataService:       crud,
+		SQLService:        sqlService,
+		Storage:           localStorage,
+		SecretKey:         secretKey,
+
+		cancel: cancel,
 	}
 }
 
@@ -88,9 +125,74 @@ func NewAppWithEnvConfig() *App {
 
 // close pool connections and potentially otrher stuff
 func (app *App) Close() {
+	app.cancel()
 	app.DB.Close()
 }
 
-func (app *App) ExecuteSQL(req *SQLExecutionRequest) (*SQLExecutionResponse, error) {
-	return req.Execute(app.DB)
+func (app *App) ExecuteSQL(ctx context.Context, req *SQLExecutionRequest) (*SQLExecutionResponse, error) {
+	return app.SQLService.Execute(ctx, req)
+}
+
+// ExplainSQL explains the query or the rows query of the table when req.Table is set,
+// table view also gets the plan of its total rows count query
+func (app *App) ExplainSQL(ctx context.Context, req *SQLExplainRequest) (*SQLExplainResponse, error) {
+	if req.Table == "" {
+		return app.SQLService.Explain(ctx, req)
+	}
+
+	q, err := url.ParseQuery(req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := ParseGetRowsParamsFromQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsSQL, err := app.DataService.GetRowsSQL(req.Table, params, req.TableView)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsReq := *req
+	rowsReq.Query, rowsReq.Args = rowsSQL.Query, rowsSQL.Args
+
+	res, err := app.SQLService.Explain(ctx, &rowsReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsSQL.CountQuery != "" {
+		countReq := *req
+		countReq.Query, countReq.Args = rowsSQL.CountQuery, rowsSQL.CountArgs
+
+		if res.Count, err = app.SQLService.Explain(ctx, &countReq); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// RunSavedQuery executes the saved query with param values converted by their types
+func (app *App) RunSavedQuery(ctx context.Context, id int64, req *SavedQueryRunRequest) (*SQLExecutionResponse, error) {
+	q, err := app.SavedQueryService.GetSavedQuery(id)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := q.Args(req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.SQLService.Execute(ctx, &SQLExecutionRequest{
+		Query:       q.TypedQuery(),
+		Args:        args,
+		QueryID:     req.QueryID,
+		TimeoutMs:   req.TimeoutMs,
+		ReadOnly:    req.ReadOnly,
+		Transaction: req.Transaction,
+	})
 }
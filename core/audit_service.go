@@ -0,0 +1,227 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ---------------------- Audit Log -------------------------------
+
+type AuditOperation string
+
+const (
+	AuditInsert         AuditOperation = "insert"
+	AuditUpsert         AuditOperation = "upsert"
+	AuditUpdate         AuditOperation = "update"
+	AuditDelete         AuditOperation = "delete"
+	AuditRestore        AuditOperation = "restore"
+	AuditRevert         AuditOperation = "revert"
+	AuditUpdateSettings AuditOperation = "updateSettings"
+	// summary entries of statements changing many rows at once
+	AuditBulkInsert AuditOperation = "bulkInsert"
+	AuditImport     AuditOperation = "import"
+	AuditRefresh    AuditOperation = "refresh"
+)
+
+const (
+	AuditTableQK     = "table"
+	AuditRowQK       = "row"
+	AuditAdminQK     = "admin"
+	AuditOperationQK = "operation"
+	AuditFromQK      = "from"
+	AuditToQK        = "to"
+
+	MaxAuditLogLimit = 1000
+)
+
+var (
+	ErrInvalidAuditParams = errors.New("invalid audit log params")
+	ErrNoSuchAuditEntry   = errors.New("no such audit log entry")
+)
+
+// AuditEntry is a change of a single row (or table settings) made through the panel.
+// Before is empty for inserted rows, After is empty for deleted rows.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Admin     string          `json:"admin"`
+	Table     string          `json:"table"`
+	Operation AuditOperation  `json:"operation"`
+	Filters   json.RawMessage `json:"filters"`
+	RowKey    json.RawMessage `json:"rowKey"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type AuditLogParams struct {
+	Table string
+	// JSON object with primary key values, e.g. {"id": 5}
+	Row        json.RawMessage
+	Admin      string
+	Operation  AuditOperation
+	From       *time.Time
+	To         *time.Time
+	Pagination Pagination
+}
+
+// ParseAuditLogParamsFromQuery parses ?table=public.users&row={"id":5}&admin=john&from=2024-01-01T00:00:00Z
+func ParseAuditLogParamsFromQuery(q url.Values) (AuditLogParams, error) {
+	params := AuditLogParams{
+		Table:      q.Get(AuditTableQK),
+		Admin:      q.Get(AuditAdminQK),
+		Operation:  AuditOperation(q.Get(AuditOperationQK)),
+		Pagination: ParsePaginationFromQuery(q),
+	}
+
+	if raw := q.Get(AuditRowQK); raw != "" {
+		if !json.Valid([]byte(raw)) {
+			return params, fmt.Errorf("%w: row has to be a JSON object", ErrInvalidAuditParams)
+		}
+		params.Row = json.RawMessage(raw)
+	}
+
+	for qk, dst := range map[string]**time.Time{AuditFromQK: &params.From, AuditToQK: &params.To} {
+		raw := q.Get(qk)
+		if raw == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return params, fmt.Errorf("%w: %s: %v", ErrInvalidAuditParams, qk, err)
+		}
+		*dst = &t
+	}
+
+	return params, nil
+}
+
+type AuditService struct {
+	db     *pgxpool.Pool
+	schema *SchemaService
+	logger *slog.Logger
+}
+
+func NewAuditService(db *pgxpool.Pool, schema *SchemaService, logger *slog.Logger) *AuditService {
+	return &AuditService{
+		db:     db,
+		schema: schema,
+		logger: logger,
+	}
+}
+
+const auditEntryColumns = `id, COALESCE(admin, ''), table_key, operation, filters, row_key, before, after, created_at`
+
+var getAuditLogSQL = SqlT(`
+	SELECT ` + auditEntryColumns + `
+	FROM pgpanel.audit_log
+	{{.Where}}
+	ORDER BY id DESC
+	LIMIT {{.Limit}}
+	OFFSET {{.Offset}}
+`)
+
+// GetAuditLog returns the latest entries first
+func (s *AuditService) GetAuditLog(params AuditLogParams) ([]AuditEntry, error) {
+	var conds []string
+	var args []any
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if params.Table != "" {
+		// dropped tables can still be queried by key
+		table := params.Table
+		if t, err := s.schema.GetTable(table); err == nil {
+			table = t.Key
+		}
+		add("table_key = $%d", table)
+	}
+	if len(params.Row) > 0 {
+		add("row_key @> $%d::jsonb", string(params.Row))
+	}
+	if params.Admin != "" {
+		add("admin = $%d", params.Admin)
+	}
+	if params.Operation != "" {
+		add("operation = $%d", string(params.Operation))
+	}
+	if params.From != nil {
+		add("created_at >= $%d", *params.From)
+	}
+	if params.To != nil {
+		add("created_at < $%d", *params.To)
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	limit := params.Pagination.Limit
+	if limit <= 0 {
+		limit = DefaultPaginationLimit
+	}
+
+	sql := getAuditLogSQL.Exec(map[string]any{
+		"Where":  where,
+		"Limit":  min(limit, MaxAuditLogLimit),
+		"Offset": params.Pagination.Offset,
+	})
+
+	rows, err := s.db.Query(context.Background(), sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := pgx.CollectRows(rows, scanAuditEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+
+	return entries, nil
+}
+
+func (s *AuditService) GetAuditEntry(id int64) (*AuditEntry, error) {
+	return queryAuditEntry(context.Background(), s.db, id)
+}
+
+func queryAuditEntry(ctx context.Context, db *pgxpool.Pool, id int64) (*AuditEntry, error) {
+	rows, err := db.Query(ctx, `SELECT `+auditEntryColumns+` FROM pgpanel.audit_log WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := pgx.CollectExactlyOneRow(rows, scanAuditEntry)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrNoSuchAuditEntry, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func scanAuditEntry(row pgx.CollectableRow) (AuditEntry, error) {
+	var e AuditEntry
+
+	err := row.Scan(&e.ID, &e.Admin, &e.Table, &e.Operation, &e.Filters, &e.RowKey, &e.Before, &e.After, &e.CreatedAt)
+
+	return e, err
+}
@@ -4,15 +4,20 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
 	DefaultSchemaName = "public"
+	// loads all non-system schemas
+	AllSchemas = "*"
 
 	DefaultSecret = "DO-NOT-USE-IN-PROD"
 )
@@ -27,14 +32,29 @@ type Config struct {
 
 	// optional fields
 	Logger           *slog.Logger
-	SchemaName       string
+	SchemaName       string // comma separated list of schemas or "*", the first one is the default
 	IncludedTables   []string
 	UploadDir        string
 	UploadKeyPattern string
+
+	// raw SQL filters are disabled by default, use structured filters instead
+	AllowRawSQLFilters bool
+
+	// tables bigger than threshold use estimated total rows count
+	CountEstimateThreshold int64
+
+	// install DDL event trigger and reload changed tables automatically
+	SchemaAutoReload bool
+
+	// upper limit of statement_timeout for SQL console queries
+	SQLMaxStatementTimeout time.Duration
+	// SQL console can't change data or schema
+	SQLReadOnly bool
 }
 
 func ParseConfigFromEnv() (*Config, error) {
 	var config Config
+	var err error
 
 	config.DatabaseUrl = os.Getenv("DATABASE_URL")
 
@@ -59,6 +79,26 @@ func ParseConfigFromEnv() (*Config, error) {
 
 	config.UploadKeyPattern = os.Getenv("UPLOAD_KEY_PATTERN")
 
+	config.AllowRawSQLFilters = os.Getenv("ALLOW_RAW_SQL_FILTERS") == "true"
+
+	config.SchemaAutoReload = os.Getenv("SCHEMA_AUTO_RELOAD") == "true"
+
+	if threshold := os.Getenv("COUNT_ESTIMATE_THRESHOLD"); threshold != "" {
+		config.CountEstimateThreshold, err = strconv.ParseInt(threshold, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid COUNT_ESTIMATE_THRESHOLD env: %w", err)
+		}
+	}
+
+	config.SQLReadOnly = os.Getenv("SQL_READ_ONLY") == "true"
+
+	if timeout := os.Getenv("SQL_MAX_STATEMENT_TIMEOUT"); timeout != "" {
+		config.SQLMaxStatementTimeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SQL_MAX_STATEMENT_TIMEOUT env: %w", err)
+		}
+	}
+
 	return &config, nil
 }
 
@@ -77,12 +117,31 @@ func (c *Config) GetPool() (*pgxpool.Pool, error) {
 	return pool, err
 }
 
+// GetSchemaName returns the default (first) schema
 func (c *Config) GetSchemaName() string {
-	if c.SchemaName == "" {
-		return DefaultSchemaName
+	for _, name := range c.GetSchemaNames() {
+		if name != AllSchemas {
+			return name
+		}
+	}
+
+	return DefaultSchemaName
+}
+
+func (c *Config) GetSchemaNames() []string {
+	var names []string
+
+	for _, name := range strings.Split(c.SchemaName, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return []string{DefaultSchemaName}
 	}
 
-	return c.SchemaName
+	return names
 }
 
 func (c *Config) GetLogger() *slog.Logger {
@@ -0,0 +1,199 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ---------------------- Aggregate Rows -------------------------------
+
+const (
+	GroupByQK   = "groupBy"
+	AggregateQK = "agg"
+
+	MaxAggregateLimit = 10_000
+)
+
+var ErrInvalidAggregate = errors.New("invalid aggregate")
+
+type AggregateFunc string
+
+const (
+	AggregateCount         AggregateFunc = "count"
+	AggregateCountDistinct AggregateFunc = "countDistinct"
+	AggregateSum           AggregateFunc = "sum"
+	AggregateAvg           AggregateFunc = "avg"
+	AggregateMin           AggregateFunc = "min"
+	AggregateMax           AggregateFunc = "max"
+)
+
+var aggregateFuncsSQL = map[AggregateFunc]string{
+	AggregateCount:         "count(%s)",
+	AggregateCountDistinct: "count(DISTINCT %s)",
+	AggregateSum:           "sum(%s)",
+	AggregateAvg:           "avg(%s)",
+	AggregateMin:           "min(%s)",
+	AggregateMax:           "max(%s)",
+}
+
+// Aggregate function applied to a column, Col is empty for count(*)
+type Aggregate struct {
+	Func AggregateFunc
+	Col  string
+}
+
+// Alias is used as the result field name: count, sum_amount, countDistinct_user_id
+func (a Aggregate) Alias() string {
+	if a.Col == "" {
+		return string(a.Func)
+	}
+
+	return string(a.Func) + "_" + a.Col
+}
+
+type AggregateParams struct {
+	GroupBy    []string
+	Aggregates []Aggregate
+	Filters    Filters
+	Sorting    Sorting
+	Pagination Pagination
+}
+
+// ParseAggregateParamsFromQuery parses ?groupBy=status|country&agg=count|sum:amount
+func ParseAggregateParamsFromQuery(q url.Values) (AggregateParams, error) {
+	filters, err := ParseFiltersFromQuery(q)
+	if err != nil {
+		return AggregateParams{}, err
+	}
+
+	params := AggregateParams{
+		Filters:    filters,
+		Sorting:    ParseSortingFromQuery(q),
+		Pagination: ParsePaginationFromQuery(q),
+	}
+
+	if raw := q.Get(GroupByQK); raw != "" {
+		params.GroupBy = strings.Split(raw, QueryArgsDelimiter)
+	}
+
+	if raw := q.Get(AggregateQK); raw != "" {
+		for _, item := range strings.Split(raw, QueryArgsDelimiter) {
+			fn, col, _ := strings.Cut(item, ":")
+
+			if _, ok := aggregateFuncsSQL[AggregateFunc(fn)]; !ok {
+				return AggregateParams{}, fmt.Errorf("%w: unknown function %q", ErrInvalidAggregate, fn)
+			}
+
+			params.Aggregates = append(params.Aggregates, Aggregate{Func: AggregateFunc(fn), Col: col})
+		}
+	}
+
+	return params, nil
+}
+
+var aggregateRowsSQL = SqlT(`
+	SELECT {{.Select}}
+	FROM {{.From}}
+	{{.Where}}
+	{{.GroupBy}}
+	{{.OrderBy}}
+	LIMIT {{.Limit}}
+	OFFSET {{.Offset}}
+`)
+
+// AggregateRows groups rows matched by filters and returns aggregates per group
+func (s DataService) AggregateRows(tableName string, params AggregateParams) (json.RawMessage, error) {
+	table, err := s.schema.GetTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args, err := s.aggregateRowsQuery(table, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queryAsJsonArray(sql, args)
+}
+
+// returns aggregate query, group columns and aggregates are validated against the table
+func (s DataService) aggregateRowsQuery(table *Table, params AggregateParams) (string, []any, error) {
+	groupColumns := table.GetColumns(params.GroupBy)
+	if len(groupColumns) != len(params.GroupBy) {
+		return "", nil, fmt.Errorf("%w: unknown group by column in %q", ErrUnknownColumn, params.GroupBy)
+	}
+
+	if len(params.Aggregates) == 0 {
+		params.Aggregates = []Aggregate{{Func: AggregateCount}}
+	}
+
+	var selectExps, groupExps, aliases []string
+
+	for _, col := range groupColumns {
+		selectExps = append(selectExps, col.SafeName())
+		groupExps = append(groupExps, col.SafeName())
+		aliases = append(aliases, col.Name)
+	}
+
+	for _, agg := range params.Aggregates {
+		arg := "*"
+
+		if agg.Col != "" {
+			cols := table.GetColumns([]string{agg.Col})
+			if len(cols) == 0 {
+				return "", nil, fmt.Errorf("%w: %q", ErrUnknownColumn, agg.Col)
+			}
+			arg = cols[0].SafeName()
+		} else if agg.Func != AggregateCount {
+			return "", nil, fmt.Errorf("%w: %q requires a column", ErrInvalidAggregate, agg.Func)
+		}
+
+		alias := agg.Alias()
+		if slices.Contains(aliases, alias) {
+			return "", nil, fmt.Errorf("%w: duplicated %q", ErrInvalidAggregate, alias)
+		}
+		aliases = append(aliases, alias)
+
+		selectExps = append(selectExps, fmt.Sprintf(`%s AS "%s"`, fmt.Sprintf(aggregateFuncsSQL[agg.Func], arg), alias))
+	}
+
+	// groups can be sorted by group columns and aggregates
+	for _, f := range params.Sorting.Fields {
+		if !slices.Contains(aliases, f.Name) {
+			return "", nil, fmt.Errorf("%w: can't sort by %q", ErrInvalidAggregate, f.Name)
+		}
+	}
+
+	if params.Sorting.IsEmpty() {
+		for _, col := range groupColumns {
+			params.Sorting.Fields = append(params.Sorting.Fields, SortingField{Name: col.Name, Order: SortingOrderASC})
+		}
+	}
+
+	where, args, err := s.filtersToSQL(table, params.Filters)
+	if err != nil {
+		return "", nil, err
+	}
+
+	groupBy := ""
+	if len(groupExps) > 0 {
+		groupBy = "GROUP BY " + strings.Join(groupExps, ", ")
+	}
+
+	sql := aggregateRowsSQL.Exec(map[string]any{
+		"Select":  strings.Join(selectExps, ", "),
+		"From":    table.SafeName(),
+		"Where":   where,
+		"GroupBy": groupBy,
+		"OrderBy": params.Sorting.ToSQL(),
+		"Limit":   min(params.Pagination.Limit, MaxAggregateLimit),
+		"Offset":  params.Pagination.Offset,
+	})
+
+	return sql, args, nil
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAggregateRowsQuery(t *testing.T) {
+	table := testTable()
+	s := DataService{schema: &SchemaService{tablesMap: TablesMap{table.Key: table}}}
+
+	tests := []struct {
+		name  string
+		query string
+		sql   string
+		args  []any
+		err   error
+	}{
+		{
+			name:  "count by default",
+			query: "groupBy=status&limit=10",
+			sql:   `SELECT "status", count(*) AS "count" FROM "public"."items" GROUP BY "status" ORDER BY "status" ASC LIMIT 10 OFFSET 0`,
+		},
+		{
+			name:  "aggregates sorted by alias",
+			query: `groupBy=status&agg=sum:price|countDistinct:email&sort=-sum_price&limit=10&textFilters=go&textFiltersCols=title`,
+			sql:   `SELECT "status", sum("price") AS "sum_price", count(DISTINCT "email") AS "countDistinct_email" FROM "public"."items" WHERE "title"::text ILIKE $1 GROUP BY "status" ORDER BY "sum_price" DESC LIMIT 10 OFFSET 0`,
+			args:  []any{"%go%"},
+		},
+		{
+			name:  "without groups",
+			query: "agg=max:price&limit=100000",
+			sql:   `SELECT max("price") AS "max_price" FROM "public"."items" LIMIT 10000 OFFSET 0`,
+		},
+		{
+			name:  "unknown group column",
+			query: "groupBy=nope",
+			err:   ErrUnknownColumn,
+		},
+		{
+			name:  "unknown aggregate column",
+			query: "agg=sum:nope",
+			err:   ErrUnknownColumn,
+		},
+		{
+			name:  "column is required",
+			query: "agg=sum",
+			err:   ErrInvalidAggregate,
+		},
+		{
+			name:  "duplicated alias",
+			query: "groupBy=status&agg=count|count",
+			err:   ErrInvalidAggregate,
+		},
+		{
+			name:  "sort by not selected column",
+			query: "groupBy=status&sort=title",
+			err:   ErrInvalidAggregate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			params, err := ParseAggregateParamsFromQuery(q)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			sql, args, err := s.aggregateRowsQuery(table, params)
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := strings.Join(strings.Fields(sql), " "); got != tt.sql {
+				t.Errorf("sql = %s, want %s", got, tt.sql)
+			}
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestParseAggregateParamsUnknownFunc(t *testing.T) {
+	q := url.Values{AggregateQK: {"median:price"}}
+
+	if _, err := ParseAggregateParamsFromQuery(q); !errors.Is(err, ErrInvalidAggregate) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidAggregate)
+	}
+}
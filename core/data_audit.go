@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ---------------------- Audited Mutations -------------------------------
+
+// WithAdmin returns a copy of the service that writes changes to the audit log on behalf of admin
+func (s DataService) WithAdmin(username string) DataService {
+	s.admin = username
+	return s
+}
+
+// mutation statement with RETURNING * written to the audit log
+type auditedStatement struct {
+	operation AuditOperation
+	sql       string
+	args      []any
+	// changed rows (empty for insert) are read before the statement to get before images
+	where     string
+	whereArgs []any
+	// rows before the change are matched with returned rows by these columns, primary key by default
+	keyColumns []string
+	// returned rows are deleted, so they are before images
+	deleted bool
+}
+
+var auditedStatementSQL = SqlT(`
+	WITH {{if .Before}}"__pgpanel_before" AS ({{.Before}}),{{end}}
+	q AS ({{.Statement}}),
+	"__pgpanel_audit" AS (
+		INSERT INTO pgpanel.audit_log (admin, table_key, operation, filters, row_key, before, after)
+		SELECT {{.Params}}, {{.RowKey}}, {{.BeforeImage}}, {{.AfterImage}}
+		FROM q {{.Join}}
+	)
+	SELECT COALESCE(json_agg(row_to_json(q)), '[]'::json) FROM q
+`)
+
+// one statement changes rows and writes the audit log, so they can't get out of sync
+func (s DataService) auditedSQL(table *Table, st auditedStatement) (string, []any) {
+	var filters any
+	if st.where != "" {
+		recorded, err := json.Marshal(map[string]any{"where": st.where, "args": st.whereArgs})
+		if err != nil {
+			// args that can't be encoded are not recorded
+			recorded, _ = json.Marshal(map[string]any{"where": st.where})
+		}
+		filters = string(recorded)
+	}
+
+	n := len(st.args)
+	args := append(append([]any{}, st.args...), s.admin, table.Key, string(st.operation), filters)
+	params := fmt.Sprintf("NULLIF($%d::text, ''), $%d::text, $%d::text, $%d::jsonb", n+1, n+2, n+3, n+4)
+
+	var pkNames, pairs []string
+	for _, col := range table.PrimaryKey() {
+		pkNames = append(pkNames, col.Name)
+		pairs = append(pairs, fmt.Sprintf("'%s', q.%s", strings.ReplaceAll(col.Name, "'", "''"), col.SafeName()))
+	}
+
+	rowKey := "NULL"
+	if len(pairs) > 0 {
+		rowKey = "jsonb_build_object(" + strings.Join(pairs, ", ") + ")"
+	}
+
+	keyColumns := st.keyColumns
+	if len(keyColumns) == 0 {
+		keyColumns = pkNames
+	}
+
+	before, join, beforeImage, afterImage := "", "", "NULL", "to_jsonb(q)"
+
+	switch {
+	case st.deleted:
+		beforeImage, afterImage = "to_jsonb(q)", "NULL"
+
+	// rows without key can't be matched, so they are recorded without before image
+	case st.where != "" && len(keyColumns) > 0:
+		var conds []string
+		for _, col := range table.GetColumns(keyColumns) {
+			conds = append(conds, fmt.Sprintf("b.%s = q.%s", col.SafeName(), col.SafeName()))
+		}
+
+		before = fmt.Sprintf("SELECT * FROM %s %s", table.SafeName(), st.where)
+		join = `LEFT JOIN "__pgpanel_before" b ON ` + strings.Join(conds, " AND ")
+		beforeImage = "to_jsonb(b)"
+	}
+
+	sql := auditedStatementSQL.Exec(map[string]any{
+		"Before":      before,
+		"Statement":   st.sql,
+		"Params":      params,
+		"RowKey":      rowKey,
+		"BeforeImage": beforeImage,
+		"AfterImage":  afterImage,
+		"Join":        join,
+	})
+
+	return sql, args
+}
+
+// returns changed rows as JSON array, the same as queryAsJsonArray
+func (s DataService) queryAudited(table *Table, st auditedStatement) (json.RawMessage, error) {
+	return s.queryAuditedTx(context.TODO(), nil, table, st)
+}
+
+// runs statement in the transaction if it's not nil
+func (s DataService) queryAuditedTx(ctx context.Context, tx pgx.Tx, table *Table, st auditedStatement) (json.RawMessage, error) {
+	sql, args := s.auditedSQL(table, st)
+
+	var row pgx.Row
+	if tx != nil {
+		row = tx.QueryRow(ctx, sql, args...)
+	} else {
+		row = s.db.QueryRow(ctx, sql, args...)
+	}
+
+	var result json.RawMessage
+	err := row.Scan(&result)
+
+	return result, err
+}
+
+var auditSummarySQL = `
+	INSERT INTO pgpanel.audit_log (admin, table_key, operation, after)
+	VALUES (NULLIF($1::text, ''), $2::text, $3::text, $4::jsonb)
+`
+
+// writes a single entry for statements changing many rows at once (COPY, import, refresh)
+// in their transaction, summary (e.g. rows count) is the after image, entry has no row key,
+// so it can't be reverted
+func (s DataService) auditSummary(ctx context.Context, tx pgx.Tx, table *Table, operation AuditOperation, summary map[string]any) error {
+	after, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, auditSummarySQL, s.admin, table.Key, string(operation), string(after))
+	return err
+}
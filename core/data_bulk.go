@@ -0,0 +1,475 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ---------------------- Bulk Insert (COPY) -------------------------------
+
+type BulkInsertOptions struct {
+	// rollback everything if at least one row is invalid,
+	// otherwise rows are inserted one by one when COPY fails (e.g. on unique violation)
+	AllOrNothing bool
+}
+
+type BulkRowError struct {
+	Row   int    `json:"row"` // index of the row in the input, -1 if unknown
+	Error string `json:"error"`
+}
+
+type BulkInsertResult struct {
+	Inserted  int64          `json:"inserted"`
+	Failed    int            `json:"failed"`
+	Committed bool           `json:"committed"`
+	Errors    []BulkRowError `json:"errors,omitempty"`
+}
+
+// BulkInsertRows loads rows from JSON array or NDJSON stream using COPY.
+// All rows must have the same set of columns as the first row.
+func (s DataService) BulkInsertRows(tableName string, r io.Reader, options BulkInsertOptions) (*BulkInsertResult, error) {
+	table, err := s.getWritableTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := newRowsDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+
+	firstRow, err := decoder.next()
+	if errors.Is(err, io.EOF) {
+		return &BulkInsertResult{Committed: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := bulkColumns(table, firstRow)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &bulkRowsSource{
+		decoder: decoder,
+		columns: columns,
+		pending: firstRow,
+		// copied rows are kept to insert them one by one if COPY fails
+		keepValues: !options.AllOrNothing,
+	}
+
+	ctx := context.Background()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := registerColumnTypes(ctx, tx.Conn(), columns); err != nil {
+		return nil, err
+	}
+
+	columnNames := make([]string, len(columns))
+	for i, col := range columns {
+		columnNames[i] = col.Name
+	}
+
+	// savepoint keeps transaction usable if COPY fails
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inserted, err := savepoint.CopyFrom(ctx, pgx.Identifier{table.Schema, table.Name}, columnNames, src)
+
+	// stream is broken, can't say anything about the rest rows
+	if src.err != nil {
+		return nil, src.err
+	}
+
+	var pgErr *pgconn.PgError
+
+	switch {
+	case err == nil:
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, err
+		}
+
+	// a single row violating a constraint fails the whole COPY, so rows are inserted one by one
+	case !options.AllOrNothing && errors.As(err, &pgErr):
+		if err := savepoint.Rollback(ctx); err != nil {
+			return nil, err
+		}
+
+		// COPY can stop before reading all rows
+		for src.Next() {
+		}
+		if src.err != nil {
+			return nil, src.err
+		}
+
+		inserted, err = insertBulkRows(ctx, tx, table, columnNames, src)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		result := &BulkInsertResult{Errors: src.errors}
+		result.Errors = append(result.Errors, BulkRowError{Row: src.copyErrorRow(err), Error: err.Error()})
+		result.Failed = src.total
+		return result, nil
+	}
+
+	result := &BulkInsertResult{Errors: src.errors}
+	result.Failed = len(src.errors)
+
+	if options.AllOrNothing && len(src.errors) > 0 {
+		result.Failed = src.total
+		return result, nil
+	}
+
+	summary := map[string]any{"inserted": inserted, "failed": result.Failed}
+	if err := s.auditSummary(ctx, tx, table, AuditBulkInsert, summary); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	result.Inserted = inserted
+	result.Committed = true
+
+	return result, nil
+}
+
+// returns table columns of the first row keys, all rows must have the same keys
+func bulkColumns(table *Table, firstRow RawRow) ([]Column, error) {
+	var columns []Column
+
+	for _, col := range table.Columns {
+		if _, ok := firstRow[col.Name]; ok {
+			columns = append(columns, col)
+		}
+	}
+
+	if len(columns) != len(firstRow) {
+		for name := range firstRow {
+			if _, ok := table.GetColumn(name); !ok {
+				return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.New("can't insert rows with zero valid columns")
+	}
+
+	return columns, nil
+}
+
+// returns insert statement of a single row with values in the columns order
+func bulkInsertRowSQL(table *Table, columnNames []string) string {
+	columns := make([]string, len(columnNames))
+	values := make([]string, len(columnNames))
+
+	for i, name := range columnNames {
+		columns[i] = fmt.Sprintf(`"%s"`, name)
+		values[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table.SafeName(), strings.Join(columns, ", "), strings.Join(values, ", "))
+}
+
+// inserts copied rows one by one in savepoints, failed rows are added to source errors
+func insertBulkRows(ctx context.Context, tx pgx.Tx, table *Table, columnNames []string, src *bulkRowsSource) (int64, error) {
+	sql := bulkInsertRowSQL(table, columnNames)
+
+	var inserted int64
+
+	for i, values := range src.rows {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := savepoint.Exec(ctx, sql, values...); err != nil {
+			if err := savepoint.Rollback(ctx); err != nil {
+				return 0, err
+			}
+
+			src.errors = append(src.errors, BulkRowError{Row: src.copied[i], Error: err.Error()})
+			continue
+		}
+
+		if err := savepoint.Commit(ctx); err != nil {
+			return 0, err
+		}
+
+		inserted += 1
+	}
+
+	slices.SortFunc(src.errors, func(a, b BulkRowError) int { return a.Row - b.Row })
+
+	return inserted, nil
+}
+
+// COPY loads data in binary format, so custom types (e.g. enums) have to be known by the connection
+func registerColumnTypes(ctx context.Context, conn *pgx.Conn, columns []Column) error {
+	typeMap := conn.TypeMap()
+
+	for _, col := range columns {
+		if _, ok := typeMap.TypeForOID(uint32(col.OID)); ok {
+			continue
+		}
+
+		typeNames := []string{col.UdtName}
+		// element type should be registered before array type
+		if col.IsArray() {
+			typeNames = []string{col.UdtName[1:], col.UdtName}
+		}
+
+		for _, typeName := range typeNames {
+			if _, ok := typeMap.TypeForName(typeName); ok {
+				continue
+			}
+
+			dt, err := conn.LoadType(ctx, typeName)
+			if err != nil {
+				return fmt.Errorf("can't load type %q for column %q: %w", typeName, col.Name, err)
+			}
+
+			typeMap.RegisterType(dt)
+		}
+	}
+
+	return nil
+}
+
+// rowsDecoder reads rows one by one from JSON array or NDJSON stream
+type rowsDecoder struct {
+	decoder *json.Decoder
+	isArray bool
+}
+
+func newRowsDecoder(r io.Reader) (*rowsDecoder, error) {
+	br := bufio.NewReader(r)
+
+	// detect input format by the first meaningful char
+	var isArray bool
+	for {
+		b, err := br.Peek(1)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.ContainsAny(b, " \t\r\n") {
+			br.ReadByte()
+			continue
+		}
+
+		isArray = b[0] == '['
+		break
+	}
+
+	decoder := json.NewDecoder(br)
+	decoder.UseNumber()
+
+	if isArray {
+		// skip opening bracket
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &rowsDecoder{decoder: decoder, isArray: isArray}, nil
+}
+
+func (d *rowsDecoder) next() (RawRow, error) {
+	if d.isArray && !d.decoder.More() {
+		return nil, io.EOF
+	}
+
+	var row RawRow
+	if err := d.decoder.Decode(&row); err != nil {
+		return nil, err
+	}
+
+	return row, nil
+}
+
+// bulkRowsSource implements pgx.CopyFromSource, invalid rows are skipped and collected as errors
+type bulkRowsSource struct {
+	decoder *rowsDecoder
+	columns []Column
+
+	pending RawRow
+	total   int
+	values  []any
+	// input index of every copied row to map COPY errors back
+	copied []int
+	errors []BulkRowError
+	err    error
+
+	// values of all copied rows in the copied order
+	keepValues bool
+	rows       [][]any
+}
+
+func (s *bulkRowsSource) Next() bool {
+	for {
+		row := s.pending
+		s.pending = nil
+
+		if row == nil {
+			var err error
+			row, err = s.decoder.next()
+
+			if errors.Is(err, io.EOF) {
+				return false
+			}
+			if err != nil {
+				s.err = err
+				return false
+			}
+		}
+
+		index := s.total
+		s.total += 1
+
+		values, err := rowCopyValues(s.columns, row)
+		if err != nil {
+			s.errors = append(s.errors, BulkRowError{Row: index, Error: err.Error()})
+			continue
+		}
+
+		s.values = values
+		s.copied = append(s.copied, index)
+
+		if s.keepValues {
+			s.rows = append(s.rows, values)
+		}
+
+		return true
+	}
+}
+
+func (s *bulkRowsSource) Values() ([]any, error) {
+	return s.values, nil
+}
+
+func (s *bulkRowsSource) Err() error {
+	return s.err
+}
+
+var copyLineRe = regexp.MustCompile(`COPY .+, line (\d+)`)
+
+// postgres reports failed COPY line in the error context
+func (s *bulkRowsSource) copyErrorRow(err error) int {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return -1
+	}
+
+	match := copyLineRe.FindStringSubmatch(pgErr.Where)
+	if match == nil {
+		return -1
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	if line < 1 || line > len(s.copied) {
+		return -1
+	}
+
+	return s.copied[line-1]
+}
+
+func rowCopyValues(columns []Column, row RawRow) ([]any, error) {
+	if len(row) != len(columns) {
+		return nil, errors.New("row columns don't match the first row columns")
+	}
+
+	values := make([]any, len(columns))
+
+	for i, col := range columns {
+		v, ok := row[col.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing column %q", col.Name)
+		}
+
+		value, err := coerceColumnValue(&col, v)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// Converts decoded JSON value to the Go value that can be encoded to the column type
+func coerceColumnValue(col *Column, v any) (any, error) {
+	if v == nil {
+		if !col.IsNullable {
+			return nil, fmt.Errorf("column %q can't be null", col.Name)
+		}
+		return nil, nil
+	}
+
+	// strings are treated as raw JSON the same way as in InsertRow
+	if col.IsJSON() {
+		if s, ok := v.(string); ok {
+			return json.RawMessage(s), nil
+		}
+		return v, nil
+	}
+
+	switch val := v.(type) {
+	case json.Number:
+		switch col.OID {
+		case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID:
+			n, err := val.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.Name, err)
+			}
+			return n, nil
+		case pgtype.Float4OID, pgtype.Float8OID:
+			n, err := val.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.Name, err)
+			}
+			return n, nil
+		}
+		// e.g. numeric will be parsed from string
+		return val.String(), nil
+	case []any:
+		if col.IsArray() {
+			return pgArrayLiteral(val)
+		}
+		return nil, fmt.Errorf("column %q doesn't accept arrays", col.Name)
+	case map[string]any:
+		return nil, fmt.Errorf("column %q doesn't accept objects", col.Name)
+	}
+
+	return v, nil
+}
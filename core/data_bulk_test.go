@@ -0,0 +1,98 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBulkColumns(t *testing.T) {
+	table := testTable()
+
+	columns, err := bulkColumns(table, RawRow{"title": "x", "id": json.Number("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// table column order is used for COPY
+	if len(columns) != 2 || columns[0].Name != "id" || columns[1].Name != "title" {
+		t.Errorf("columns = %v", columns)
+	}
+
+	if _, err := bulkColumns(table, RawRow{"id": json.Number("1"), "nope": 1}); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+
+	if _, err := bulkColumns(table, RawRow{}); err == nil {
+		t.Error("expected error for zero columns")
+	}
+}
+
+func TestBulkInsertRowSQL(t *testing.T) {
+	want := `INSERT INTO "public"."items" ("id", "title") VALUES ($1, $2)`
+
+	if got := bulkInsertRowSQL(testTable(), []string{"id", "title"}); got != want {
+		t.Errorf("sql = %s, want %s", got, want)
+	}
+}
+
+func TestRowCopyValues(t *testing.T) {
+	table := testTable()
+	// columns are in the table order
+	columns := table.GetColumns([]string{"id", "title", "price", "tags", "meta"})
+
+	tests := []struct {
+		name   string
+		row    RawRow
+		values []any
+		err    bool
+	}{
+		{
+			name:   "coerced values",
+			row:    RawRow{"id": json.Number("1"), "title": "x", "tags": []any{"a", "b"}, "meta": `{"a":1}`, "price": json.Number("1.50")},
+			values: []any{int64(1), "x", "1.50", `{"a","b"}`, json.RawMessage(`{"a":1}`)},
+		},
+		{
+			name:   "nulls in nullable columns",
+			row:    RawRow{"id": json.Number("1"), "title": "x", "tags": nil, "meta": nil, "price": nil},
+			values: []any{int64(1), "x", nil, nil, nil},
+		},
+		{
+			name: "null in not nullable column",
+			row:  RawRow{"id": nil, "title": "x", "tags": nil, "meta": nil, "price": nil},
+			err:  true,
+		},
+		{
+			name: "different keys",
+			row:  RawRow{"id": json.Number("1"), "title": "x"},
+			err:  true,
+		},
+		{
+			name: "array in scalar column",
+			row:  RawRow{"id": json.Number("1"), "title": []any{"x"}, "tags": nil, "meta": nil, "price": nil},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := rowCopyValues(columns, tt.row)
+
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("values = %#v, want %#v", values, tt.values)
+			}
+		})
+	}
+}
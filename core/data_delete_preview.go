@@ -0,0 +1,248 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ---------------------- Delete Preview -------------------------------
+
+const MaxDeletePreviewDepth = 5
+
+type DeleteRule string
+
+// confdeltype values of pg_constraint
+var deleteRules = map[string]DeleteRule{
+	"c": "cascade",
+	"n": "setNull",
+	"d": "setDefault",
+	"r": "restrict",
+	"a": "noAction",
+}
+
+// DeleteImpact is a foreign key to rows that would be deleted.
+// Rows are deleted (cascade), nulled (setNull/setDefault) or block the delete (restrict/noAction).
+type DeleteImpact struct {
+	Table      string     `json:"table"`
+	Constraint string     `json:"constraint"`
+	Columns    []string   `json:"columns"`
+	Rule       DeleteRule `json:"rule"`
+	Rows       int64      `json:"rows"`
+	Depth      int        `json:"depth"`
+}
+
+// TableChanges are exact row changes made by the delete, including cascades and triggers
+type TableChanges struct {
+	Table   string `json:"table"`
+	Deleted int64  `json:"deleted"`
+	Updated int64  `json:"updated"`
+}
+
+type DeletePreview struct {
+	Rows    int64          `json:"rows"`
+	Impacts []DeleteImpact `json:"impacts"`
+	Changes []TableChanges `json:"changes"`
+	// delete executed in the rolled-back transaction has failed (e.g. restricted by a foreign key)
+	Blocked bool   `json:"blocked"`
+	Error   string `json:"error,omitempty"`
+	// rows are only marked as deleted, foreign keys aren't involved
+	SoftDelete bool `json:"softDelete"`
+}
+
+var countDeleteSetSQL = SqlT(`
+	SELECT COUNT(*) FROM ({{.Set}}) q
+`)
+
+// rows of the child table referencing rows of the parent set
+var childDeleteSetSQL = SqlT(`
+	SELECT * FROM {{.TableName}} c
+	WHERE ({{.Columns}}) IN (SELECT {{.ReferencedColumns}} FROM ({{.ParentSet}}) p)
+`)
+
+// PreviewDeleteRows reports what DeleteRows would do with the same filters.
+// Foreign keys are walked to count affected rows in dependent tables, then
+// the delete is executed in a rolled-back transaction to get exact changes.
+func (s DataService) PreviewDeleteRows(tableName string, filters Filters) (*DeletePreview, error) {
+	table, err := s.getWritableTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	where, args, err := s.userFiltersToSQL(table, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(where) == 0 {
+		return nil, errors.New("can't delete rows with empty filters")
+	}
+
+	where = s.notDeletedSQL(table, filters, where)
+
+	ctx := context.Background()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	set := fmt.Sprintf("SELECT * FROM %s %s", table.SafeName(), where)
+
+	preview := &DeletePreview{Impacts: []DeleteImpact{}, Changes: []TableChanges{}}
+
+	if err := tx.QueryRow(ctx, countDeleteSetSQL.Exec(map[string]any{"Set": set}), args...).Scan(&preview.Rows); err != nil {
+		return nil, err
+	}
+
+	preview.SoftDelete = s.softDelete(table) != nil
+
+	if !preview.SoftDelete {
+		if err := s.walkDeleteImpacts(ctx, tx, table.SafeName(), set, args, 1, []string{table.Key}, preview); err != nil {
+			return nil, err
+		}
+	}
+
+	// savepoint keeps transaction usable if the delete fails
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deleteSQL, deleteArgs := s.deleteStatement(table, where, args)
+	_, err = savepoint.Exec(ctx, deleteSQL, deleteArgs...)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		preview.Blocked = true
+		preview.Error = pgErr.Error()
+		return preview, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// transaction level statistics include cascades and changes made by triggers
+	rows, err := savepoint.Query(ctx, `
+		SELECT schemaname || '.' || relname, n_tup_del, n_tup_upd
+		FROM pg_stat_xact_user_tables
+		WHERE n_tup_del > 0 OR n_tup_upd > 0
+		ORDER BY schemaname, relname
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	preview.Changes, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (TableChanges, error) {
+		var c TableChanges
+		err := row.Scan(&c.Table, &c.Deleted, &c.Updated)
+		return c, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// foreign key referencing the table of the parent set
+type deleteForeignKey struct {
+	constraint, safeName, tableKey, rule string
+	columns, referencedColumns           []string
+}
+
+// returns rows of the child table referencing rows of the parent set
+func (fk deleteForeignKey) childSet(parentSet string) string {
+	quote := func(names []string, prefix string) string {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = fmt.Sprintf(`%s."%s"`, prefix, name)
+		}
+		return strings.Join(quoted, ", ")
+	}
+
+	return childDeleteSetSQL.Exec(map[string]any{
+		"TableName":         fk.safeName,
+		"Columns":           quote(fk.columns, "c"),
+		"ReferencedColumns": quote(fk.referencedColumns, "p"),
+		"ParentSet":         parentSet,
+	})
+}
+
+// cascades are followed until max depth, tables already on the path (e.g. self references) are skipped
+func (fk deleteForeignKey) followCascade(depth int, path []string) bool {
+	return deleteRules[fk.rule] == "cascade" && depth < MaxDeletePreviewDepth && !slices.Contains(path, fk.tableKey)
+}
+
+// walkDeleteImpacts counts rows referencing the parent set for every foreign key
+// and follows cascades. Path of table keys is used to stop on cyclic cascades.
+// Impacts are informational, the delete is blocked only if it actually fails.
+func (s DataService) walkDeleteImpacts(ctx context.Context, tx pgx.Tx, tableName string, parentSet string, args []any, depth int, path []string, preview *DeletePreview) error {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			con.conname,
+			format('%I.%I', n.nspname, c.relname) AS safe_name,
+			n.nspname || '.' || c.relname AS table_key,
+			con.confdeltype::text,
+			array_agg(ca.attname::text ORDER BY k.ord) AS columns,
+			array_agg(ra.attname::text ORDER BY k.ord) AS referenced_columns
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord)
+		JOIN pg_attribute ca ON ca.attrelid = con.conrelid AND ca.attnum = k.attnum
+		JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refattnum
+		WHERE con.contype = 'f' AND con.confrelid = $1::regclass
+		GROUP BY con.conname, n.nspname, c.relname, con.confdeltype
+		ORDER BY n.nspname, c.relname, con.conname
+	`, tableName)
+	if err != nil {
+		return err
+	}
+
+	fks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (deleteForeignKey, error) {
+		var fk deleteForeignKey
+		err := row.Scan(&fk.constraint, &fk.safeName, &fk.tableKey, &fk.rule, &fk.columns, &fk.referencedColumns)
+		return fk, err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, fk := range fks {
+		childSet := fk.childSet(parentSet)
+
+		impact := DeleteImpact{
+			Table:      fk.tableKey,
+			Constraint: fk.constraint,
+			Columns:    fk.columns,
+			Rule:       deleteRules[fk.rule],
+			Depth:      depth,
+		}
+
+		if err := tx.QueryRow(ctx, countDeleteSetSQL.Exec(map[string]any{"Set": childSet}), args...).Scan(&impact.Rows); err != nil {
+			return err
+		}
+
+		if impact.Rows == 0 {
+			continue
+		}
+
+		preview.Impacts = append(preview.Impacts, impact)
+
+		if fk.followCascade(depth, path) {
+			if err := s.walkDeleteImpacts(ctx, tx, fk.safeName, childSet, args, depth+1, append(slices.Clone(path), fk.tableKey), preview); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeleteForeignKeyChildSet(t *testing.T) {
+	fk := deleteForeignKey{
+		safeName:          `public.order_items`,
+		columns:           []string{"tenant_id", "order_id"},
+		referencedColumns: []string{"tenant_id", "id"},
+	}
+
+	got := strings.Join(strings.Fields(fk.childSet(`SELECT * FROM "public"."orders" WHERE id = $1`)), " ")
+	want := `SELECT * FROM public.order_items c WHERE (c."tenant_id", c."order_id") IN ` +
+		`(SELECT p."tenant_id", p."id" FROM (SELECT * FROM "public"."orders" WHERE id = $1) p)`
+
+	if got != want {
+		t.Errorf("child set = %s, want %s", got, want)
+	}
+}
+
+func TestDeleteForeignKeyFollowCascade(t *testing.T) {
+	tests := []struct {
+		name  string
+		fk    deleteForeignKey
+		depth int
+		path  []string
+		want  bool
+	}{
+		{"cascade", deleteForeignKey{tableKey: "public.items", rule: "c"}, 1, []string{"public.orders"}, true},
+		{"self reference", deleteForeignKey{tableKey: "public.orders", rule: "c"}, 1, []string{"public.orders"}, false},
+		{"cycle", deleteForeignKey{tableKey: "public.a", rule: "c"}, 2, []string{"public.a", "public.b"}, false},
+		{"max depth", deleteForeignKey{tableKey: "public.items", rule: "c"}, MaxDeletePreviewDepth, []string{"public.orders"}, false},
+		{"set null", deleteForeignKey{tableKey: "public.items", rule: "n"}, 1, []string{"public.orders"}, false},
+		{"restrict", deleteForeignKey{tableKey: "public.items", rule: "r"}, 1, []string{"public.orders"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fk.followCascade(tt.depth, tt.path); got != tt.want {
+				t.Errorf("follow = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
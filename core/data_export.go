@@ -0,0 +1,309 @@
+package core
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ---------------------- Export Rows -------------------------------
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	ExportFormatXLSX   ExportFormat = "xlsx"
+
+	exportFetchSize = 1000
+)
+
+var errTooManyXlsxRows = errors.New("too many rows for xlsx, use csv or ndjson")
+
+func ParseExportFormat(format string) (ExportFormat, error) {
+	switch f := ExportFormat(strings.ToLower(format)); f {
+	case "":
+		return ExportFormatCSV, nil
+	case ExportFormatCSV, ExportFormatNDJSON, ExportFormatXLSX:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("unknown export format %q", format)
+}
+
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportFormatNDJSON:
+		return "application/x-ndjson"
+	case ExportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv"
+}
+
+var exportRowsSQL = SqlT(`
+	SELECT {{.Select}}
+	FROM {{.From}}
+	{{.Where}}
+	{{.OrderBy}}
+`)
+
+var exportJsonRowsSQL = SqlT(`
+	SELECT row_to_json(q)::text
+	FROM (
+		SELECT {{.Select}}
+		FROM {{.From}}
+		{{.Where}}
+		{{.OrderBy}}
+	) q
+`)
+
+// ExportRows streams all rows matched by params (pagination is ignored) using a server-side cursor
+func (s DataService) ExportRows(tableName string, params GetRowsParams, format ExportFormat, w io.Writer) error {
+	table, err := s.schema.GetTable(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	if params.WithLabels {
+		table = s.labeledTable(table)
+	}
+
+	sql, args, columns, err := s.exportRowsQuery(table, params, format)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DECLARE pgpanel_export NO SCROLL CURSOR FOR "+sql, args...); err != nil {
+		return err
+	}
+
+	writer, err := newRowsExportWriter(format, w, columns)
+	if err != nil {
+		return err
+	}
+
+	fetchSQL := fmt.Sprintf("FETCH FORWARD %d FROM pgpanel_export", exportFetchSize)
+
+	for {
+		rows, err := tx.Query(ctx, fetchSQL)
+		if err != nil {
+			return err
+		}
+
+		fetched := 0
+		for rows.Next() {
+			values := make([]*string, len(rows.FieldDescriptions()))
+			ptrs := make([]any, len(values))
+			for i := range values {
+				ptrs[i] = &values[i]
+			}
+
+			if err := rows.Scan(ptrs...); err != nil {
+				rows.Close()
+				return err
+			}
+
+			if err := writer.WriteRow(values); err != nil {
+				rows.Close()
+				return err
+			}
+
+			fetched += 1
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	return writer.Close()
+}
+
+// returns export query selecting text values (or JSON objects for NDJSON) and exported columns
+func (s DataService) exportRowsQuery(table *Table, params GetRowsParams, format ExportFormat) (string, []any, []Column, error) {
+	where, args, err := s.filtersToSQL(table, params.Filters)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if params.Sorting.IsEmpty() {
+		params.Sorting = DefaultTableSorting(table)
+	}
+
+	columns := params.SelectColumns.ToColumns(table)
+
+	sqlParams := map[string]any{
+		"Select":  params.SelectColumns.ToSQL(table),
+		"From":    table.FromSQL(),
+		"Where":   where,
+		"OrderBy": params.Sorting.ToSQL(),
+	}
+
+	// NDJSON keeps the same values as data API, other formats use postgres text representation
+	var sql string
+	if format == ExportFormatNDJSON {
+		sql = exportJsonRowsSQL.Exec(sqlParams)
+	} else {
+		textExps := make([]string, len(columns))
+		for i, col := range columns {
+			textExps[i] = fmt.Sprintf("%s::text", col.SafeName())
+		}
+
+		sqlParams["Select"] = strings.Join(textExps, ", ")
+		sql = exportRowsSQL.Exec(sqlParams)
+	}
+
+	return sql, args, columns, nil
+}
+
+type rowsExportWriter interface {
+	WriteRow(values []*string) error
+	Close() error
+}
+
+func newRowsExportWriter(format ExportFormat, w io.Writer, columns []Column) (rowsExportWriter, error) {
+	switch format {
+	case ExportFormatNDJSON:
+		return &ndjsonExportWriter{w: w}, nil
+	case ExportFormatXLSX:
+		return newXlsxExportWriter(w, columns)
+	}
+
+	return newCsvExportWriter(w, columns)
+}
+
+// CSV
+type csvExportWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCsvExportWriter(w io.Writer, columns []Column) (*csvExportWriter, error) {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Name
+	}
+
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &csvExportWriter{w: cw, record: make([]string, len(columns))}, nil
+}
+
+func (cw *csvExportWriter) WriteRow(values []*string) error {
+	for i, v := range values {
+		cw.record[i] = ""
+		if v != nil {
+			cw.record[i] = *v
+		}
+	}
+
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvExportWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// NDJSON
+type ndjsonExportWriter struct {
+	w io.Writer
+}
+
+func (nw *ndjsonExportWriter) WriteRow(values []*string) error {
+	if len(values) == 0 || values[0] == nil {
+		return nil
+	}
+
+	_, err := io.WriteString(nw.w, *values[0]+"\n")
+	return err
+}
+
+func (nw *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// XLSX
+type xlsxExportWriter struct {
+	x       *xlsxWriter
+	columns []Column
+	cells   []xlsxCell
+}
+
+func newXlsxExportWriter(w io.Writer, columns []Column) (*xlsxExportWriter, error) {
+	x, err := newXlsxWriter(w)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]xlsxCell, len(columns))
+	for i, col := range columns {
+		header[i] = xlsxCell{Value: col.Name}
+	}
+
+	if err := x.WriteRow(header); err != nil {
+		return nil, err
+	}
+
+	return &xlsxExportWriter{x: x, columns: columns, cells: make([]xlsxCell, len(columns))}, nil
+}
+
+func (xw *xlsxExportWriter) WriteRow(values []*string) error {
+	for i, v := range values {
+		if v == nil {
+			xw.cells[i] = xlsxCell{IsNull: true}
+			continue
+		}
+
+		xw.cells[i] = xlsxCell{Value: *v, IsNumber: isNumericOID(xw.columns[i].OID) && isFiniteNumber(*v)}
+	}
+
+	return xw.x.WriteRow(xw.cells)
+}
+
+func (xw *xlsxExportWriter) Close() error {
+	return xw.x.Close()
+}
+
+// NaN and Infinity can't be stored as number cells
+func isFiniteNumber(s string) bool {
+	f, err := strconv.ParseFloat(s, 64)
+	return err == nil && !math.IsInf(f, 0) && !math.IsNaN(f)
+}
+
+func isNumericOID(oid int) bool {
+	switch oid {
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.Float4OID, pgtype.Float8OID, pgtype.NumericOID:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportRowsQuery(t *testing.T) {
+	table := testTable()
+	s := DataService{AllowRawSQLFilters: true, schema: &SchemaService{tablesMap: TablesMap{table.Key: table}}}
+
+	tests := []struct {
+		name    string
+		query   string
+		format  ExportFormat
+		sql     string
+		args    []any
+		columns []string
+	}{
+		{
+			name:    "csv",
+			query:   "selectCols=id|title&sort=-title&filters=id > $1&filtersArgs=5",
+			format:  ExportFormatCSV,
+			sql:     `SELECT "id"::text, "title"::text FROM "public"."items" WHERE id > $1 ORDER BY "title" DESC`,
+			args:    []any{"5"},
+			columns: []string{"id", "title"},
+		},
+		{
+			name:    "ndjson",
+			query:   "selectCols=id|title",
+			format:  ExportFormatNDJSON,
+			sql:     `SELECT row_to_json(q)::text FROM ( SELECT "id", "title" FROM "public"."items" ORDER BY "id" ASC ) q`,
+			columns: []string{"id", "title"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			params, err := ParseGetRowsParamsFromQuery(q)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			sql, args, columns, err := s.exportRowsQuery(table, params, tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := strings.Join(strings.Fields(sql), " "); got != tt.sql {
+				t.Errorf("sql = %s, want %s", got, tt.sql)
+			}
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+
+			var names []string
+			for _, col := range columns {
+				names = append(names, col.Name)
+			}
+
+			if !reflect.DeepEqual(names, tt.columns) {
+				t.Errorf("columns = %v, want %v", names, tt.columns)
+			}
+		})
+	}
+}
+
+func TestExportRowsQueryRawSQLForbidden(t *testing.T) {
+	table := testTable()
+	s := DataService{schema: &SchemaService{tablesMap: TablesMap{table.Key: table}}}
+
+	params, _ := ParseGetRowsParamsFromQuery(url.Values{"filters": {"id > 5"}})
+
+	if _, _, _, err := s.exportRowsQuery(table, params, ExportFormatCSV); !errors.Is(err, ErrRawSQLFiltersForbidden) {
+		t.Errorf("err = %v, want %v", err, ErrRawSQLFiltersForbidden)
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want ExportFormat
+		err  bool
+	}{
+		{"", ExportFormatCSV, false},
+		{"NDJSON", ExportFormatNDJSON, false},
+		{"xlsx", ExportFormatXLSX, false},
+		{"pdf", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseExportFormat(tt.raw)
+
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("%q: format = %q, err = %v", tt.raw, got, err)
+		}
+	}
+}
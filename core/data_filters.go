@@ -0,0 +1,346 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownColumn          = errors.New("unknown column")
+	ErrInvalidFilters         = errors.New("invalid filters")
+	ErrRawSQLFiltersForbidden = errors.New("raw SQL filters are not allowed")
+)
+
+// ---------------------- StructuredFilters -------------------------------
+
+type FilterOp string
+
+const (
+	FilterOpEq       FilterOp = "eq"
+	FilterOpNeq      FilterOp = "neq"
+	FilterOpLt       FilterOp = "lt"
+	FilterOpLte      FilterOp = "lte"
+	FilterOpGt       FilterOp = "gt"
+	FilterOpGte      FilterOp = "gte"
+	FilterOpIn       FilterOp = "in"
+	FilterOpLike     FilterOp = "like"
+	FilterOpIlike    FilterOp = "ilike"
+	FilterOpIsNull   FilterOp = "isNull"
+	FilterOpNotNull  FilterOp = "notNull"
+	FilterOpBetween  FilterOp = "between"
+	FilterOpContains FilterOp = "contains"
+)
+
+var comparisonOperators = map[FilterOp]string{
+	FilterOpEq:  "=",
+	FilterOpNeq: "<>",
+	FilterOpLt:  "<",
+	FilterOpLte: "<=",
+	FilterOpGt:  ">",
+	FilterOpGte: ">=",
+}
+
+// FilterExpr is a node of the structured filters tree.
+// It is either a group (And / Or) or a single column condition:
+//
+//	{"and": [{"col": "status", "op": "eq", "value": "paid"}, {"or": [...]}]}
+type FilterExpr struct {
+	And []FilterExpr `json:"and,omitempty"`
+	Or  []FilterExpr `json:"or,omitempty"`
+
+	Col   string   `json:"col,omitempty"`
+	Op    FilterOp `json:"op,omitempty"`
+	Value any      `json:"value,omitempty"`
+}
+
+func (e FilterExpr) isGroup() bool {
+	return e.And != nil || e.Or != nil
+}
+
+func (e FilterExpr) IsEmpty() bool {
+	return !e.isGroup() && len(e.Col) == 0
+}
+
+// Column condition helpers to build filters in code
+func FilterEq(col string, value any) FilterExpr {
+	return FilterExpr{Col: col, Op: FilterOpEq, Value: value}
+}
+
+func FilterAnd(exprs ...FilterExpr) FilterExpr {
+	return FilterExpr{And: exprs}
+}
+
+func FilterOr(exprs ...FilterExpr) FilterExpr {
+	return FilterExpr{Or: exprs}
+}
+
+type StructuredFilters struct {
+	Expr FilterExpr
+}
+
+// ParseStructuredFilters accepts a JSON object (single expression)
+// or a JSON array (expressions combined with AND)
+func ParseStructuredFilters(raw string) (StructuredFilters, error) {
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	// keep numbers as is to not lose bigint precision
+	decoder.UseNumber()
+
+	var expr FilterExpr
+
+	if strings.HasPrefix(strings.TrimSpace(raw), "[") {
+		var exprs []FilterExpr
+		if err := decoder.Decode(&exprs); err != nil {
+			return StructuredFilters{}, fmt.Errorf("%w: %v", ErrInvalidFilters, err)
+		}
+		// empty list means no filters, so it can't bypass "no filters" guards with WHERE TRUE
+		if len(exprs) == 0 {
+			return StructuredFilters{}, nil
+		}
+		expr = FilterAnd(exprs...)
+	} else if err := decoder.Decode(&expr); err != nil {
+		return StructuredFilters{}, fmt.Errorf("%w: %v", ErrInvalidFilters, err)
+	}
+
+	return StructuredFilters{Expr: expr}, nil
+}
+
+func (f StructuredFilters) ToSQL(t *Table) (string, []any, error) {
+	if f.Expr.IsEmpty() {
+		return "", nil, nil
+	}
+
+	var args []any
+	sql, err := f.Expr.toSQL(t, &args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return "WHERE " + sql, args, nil
+}
+
+func (e FilterExpr) toSQL(t *Table, args *[]any) (string, error) {
+	if e.isGroup() {
+		return e.groupToSQL(t, args)
+	}
+
+	col, ok := t.GetColumn(e.Col)
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownColumn, e.Col)
+	}
+
+	// every value goes as a bind param, its type is inferred from the column
+	bind := func(v any) (string, error) {
+		arg, err := filterArgValue(col, v)
+		if err != nil {
+			return "", err
+		}
+
+		*args = append(*args, arg)
+		return fmt.Sprintf("$%d", len(*args)), nil
+	}
+
+	bindList := func(v any, size int) ([]string, error) {
+		list, ok := v.([]any)
+		if !ok || (size > 0 && len(list) != size) {
+			return nil, fmt.Errorf("%w: %q operator on %q expects an array value", ErrInvalidFilters, e.Op, e.Col)
+		}
+
+		params := make([]string, len(list))
+		for i, item := range list {
+			p, err := bind(item)
+			if err != nil {
+				return nil, err
+			}
+			params[i] = p
+		}
+
+		return params, nil
+	}
+
+	name := col.SafeName()
+
+	if sqlOp, ok := comparisonOperators[e.Op]; ok {
+		// handle null comparison the way users expect it
+		if e.Value == nil && e.Op == FilterOpEq {
+			return name + " IS NULL", nil
+		}
+		if e.Value == nil && e.Op == FilterOpNeq {
+			return name + " IS NOT NULL", nil
+		}
+
+		p, err := bind(e.Value)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("%s %s %s", name, sqlOp, p), nil
+	}
+
+	switch e.Op {
+	case FilterOpIsNull:
+		return name + " IS NULL", nil
+
+	case FilterOpNotNull:
+		return name + " IS NOT NULL", nil
+
+	case FilterOpLike, FilterOpIlike:
+		p, err := bind(e.Value)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("%s::text %s %s", name, strings.ToUpper(string(e.Op)), p), nil
+
+	case FilterOpIn:
+		params, err := bindList(e.Value, 0)
+		if err != nil {
+			return "", err
+		}
+
+		if len(params) == 0 {
+			return "FALSE", nil
+		}
+
+		return fmt.Sprintf("%s IN (%s)", name, strings.Join(params, ", ")), nil
+
+	case FilterOpBetween:
+		params, err := bindList(e.Value, 2)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("%s BETWEEN %s AND %s", name, params[0], params[1]), nil
+
+	case FilterOpContains:
+		p, err := bind(e.Value)
+		if err != nil {
+			return "", err
+		}
+
+		// json type doesn't have @> operator
+		if col.UdtName == "json" {
+			return fmt.Sprintf("%s::jsonb @> %s::jsonb", name, p), nil
+		}
+
+		if col.IsArray() || col.IsJSON() {
+			return fmt.Sprintf("%s @> %s", name, p), nil
+		}
+
+		return fmt.Sprintf("strpos(%s::text, %s) > 0", name, p), nil
+	}
+
+	return "", fmt.Errorf("%w: unknown operator %q", ErrInvalidFilters, e.Op)
+}
+
+func (e FilterExpr) groupToSQL(t *Table, args *[]any) (string, error) {
+	if e.And != nil && e.Or != nil {
+		return "", fmt.Errorf("%w: group can't have both and/or", ErrInvalidFilters)
+	}
+
+	exprs, joiner := e.And, " AND "
+	if e.Or != nil {
+		exprs, joiner = e.Or, " OR "
+	}
+
+	// empty AND group would match all rows and turn update/delete into the whole table one
+	if len(exprs) == 0 {
+		return "", fmt.Errorf("%w: empty %s group", ErrInvalidFilters, strings.ToLower(strings.TrimSpace(joiner)))
+	}
+
+	parts := make([]string, len(exprs))
+	for i, sub := range exprs {
+		sql, err := sub.toSQL(t, args)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = sql
+	}
+
+	return "(" + strings.Join(parts, joiner) + ")", nil
+}
+
+// Converts decoded JSON value to the text representation that postgres
+// will parse according to the column type.
+func filterArgValue(col *Column, v any) (any, error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("%w: null value for %q, use isNull/notNull", ErrInvalidFilters, col.Name)
+	case string:
+		if col.IsJSON() {
+			return jsonString(val)
+		}
+		return val, nil
+	case json.Number:
+		return val.String(), nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(val), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case []any:
+		if col.IsArray() {
+			return pgArrayLiteral(val)
+		}
+		return jsonString(val)
+	default:
+		return jsonString(val)
+	}
+}
+
+func jsonString(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// Builds postgres array literal like {"a","b",NULL}
+func pgArrayLiteral(list []any) (string, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+
+	for i, item := range list {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		var s string
+
+		switch val := item.(type) {
+		case nil:
+			b.WriteString("NULL")
+			continue
+		case []any:
+			nested, err := pgArrayLiteral(val)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(nested)
+			continue
+		case string:
+			s = val
+		case json.Number:
+			s = val.String()
+		case float64:
+			s = strconv.FormatFloat(val, 'f', -1, 64)
+		case bool:
+			s = strconv.FormatBool(val)
+		default:
+			encoded, err := jsonString(val)
+			if err != nil {
+				return "", err
+			}
+			s = encoded
+		}
+
+		b.WriteByte('"')
+		b.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s))
+		b.WriteByte('"')
+	}
+
+	b.WriteByte('}')
+	return b.String(), nil
+}
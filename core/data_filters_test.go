@@ -0,0 +1,143 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStructuredFiltersToSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		where string
+		args  []any
+		err   error
+	}{
+		{
+			name:  "eq",
+			raw:   `{"col": "status", "op": "eq", "value": "paid"}`,
+			where: `WHERE "status" = $1`,
+			args:  []any{"paid"},
+		},
+		{
+			name:  "array is and",
+			raw:   `[{"col": "id", "op": "gt", "value": 5}, {"col": "status", "op": "neq", "value": null}]`,
+			where: `WHERE ("id" > $1 AND "status" IS NOT NULL)`,
+			args:  []any{"5"},
+		},
+		{
+			name:  "nested or",
+			raw:   `{"and": [{"col": "id", "op": "in", "value": [1, 2]}, {"or": [{"col": "status", "op": "isNull"}, {"col": "status", "op": "ilike", "value": "%a%"}]}]}`,
+			where: `WHERE ("id" IN ($1, $2) AND ("status" IS NULL OR "status"::text ILIKE $3))`,
+			args:  []any{"1", "2", "%a%"},
+		},
+		{
+			name:  "between",
+			raw:   `{"col": "id", "op": "between", "value": [1, 10]}`,
+			where: `WHERE "id" BETWEEN $1 AND $2`,
+			args:  []any{"1", "10"},
+		},
+		{
+			name:  "empty in",
+			raw:   `{"col": "id", "op": "in", "value": []}`,
+			where: `WHERE FALSE`,
+		},
+		{
+			name:  "array contains",
+			raw:   `{"col": "tags", "op": "contains", "value": ["a", "b"]}`,
+			where: `WHERE "tags" @> $1`,
+			args:  []any{`{"a","b"}`},
+		},
+		{
+			name:  "jsonb contains",
+			raw:   `{"col": "meta", "op": "contains", "value": {"a": 1}}`,
+			where: `WHERE "meta" @> $1`,
+			args:  []any{`{"a":1}`},
+		},
+		{
+			name:  "json contains",
+			raw:   `{"col": "payload", "op": "contains", "value": {"a": 1}}`,
+			where: `WHERE "payload"::jsonb @> $1::jsonb`,
+			args:  []any{`{"a":1}`},
+		},
+		{
+			name:  "text contains",
+			raw:   `{"col": "status", "op": "contains", "value": "ai"}`,
+			where: `WHERE strpos("status"::text, $1) > 0`,
+			args:  []any{"ai"},
+		},
+		{
+			name: "empty list is no filters",
+			raw:  `[]`,
+		},
+		{
+			name: "empty and group",
+			raw:  `{"and": []}`,
+			err:  ErrInvalidFilters,
+		},
+		{
+			name: "nested empty or group",
+			raw:  `[{"col": "id", "op": "eq", "value": 1}, {"or": []}]`,
+			err:  ErrInvalidFilters,
+		},
+		{
+			name: "unknown column",
+			raw:  `{"col": "nope", "op": "eq", "value": 1}`,
+			err:  ErrUnknownColumn,
+		},
+		{
+			name: "unknown operator",
+			raw:  `{"col": "id", "op": "regex", "value": 1}`,
+			err:  ErrInvalidFilters,
+		},
+		{
+			name: "null value",
+			raw:  `{"col": "id", "op": "gt", "value": null}`,
+			err:  ErrInvalidFilters,
+		},
+		{
+			name: "between expects two values",
+			raw:  `{"col": "id", "op": "between", "value": [1]}`,
+			err:  ErrInvalidFilters,
+		},
+	}
+
+	table := testTable()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := ParseStructuredFilters(tt.raw)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			where, args, err := filters.ToSQL(table)
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if where != tt.where {
+				t.Errorf("where = %q, want %q", where, tt.where)
+			}
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestParseStructuredFiltersInvalidJSON(t *testing.T) {
+	if _, err := ParseStructuredFilters(`{"col": `); !errors.Is(err, ErrInvalidFilters) {
+		t.Fatalf("expected ErrInvalidFilters, got %v", err)
+	}
+}
@@ -0,0 +1,426 @@
+package core
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ---------------------- Import Rows (CSV / JSON) -------------------------------
+
+type ImportFormat string
+
+type ImportConflictAction string
+
+const (
+	ImportFormatCSV  ImportFormat = "csv"
+	ImportFormatJSON ImportFormat = "json"
+
+	ImportConflictFail   ImportConflictAction = "fail"
+	ImportConflictSkip   ImportConflictAction = "skip"
+	ImportConflictUpdate ImportConflictAction = "update"
+
+	MaxImportReportedErrors = 100
+)
+
+type ImportOptions struct {
+	Format ImportFormat `json:"format"`
+	// file header -> table column, headers with the same names as columns are used when empty
+	Mapping    map[string]string    `json:"mapping"`
+	DryRun     bool                 `json:"dryRun"`
+	OnConflict ImportConflictAction `json:"onConflict"`
+}
+
+type ImportResult struct {
+	Total     int            `json:"total"`
+	Inserted  int            `json:"inserted"`
+	Updated   int            `json:"updated"`
+	Skipped   int            `json:"skipped"`
+	Failed    int            `json:"failed"`
+	DryRun    bool           `json:"dryRun"`
+	Committed bool           `json:"committed"`
+	Errors    []BulkRowError `json:"errors,omitempty"`
+}
+
+// detects format by file name if not set explicitly
+func ParseImportFormat(format string, fileName string) (ImportFormat, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(fileName), ".")
+	}
+
+	switch f := ImportFormat(strings.ToLower(format)); f {
+	case ImportFormatCSV, ImportFormatJSON:
+		return f, nil
+	case "ndjson":
+		return ImportFormatJSON, nil
+	}
+
+	return "", fmt.Errorf("unknown import format %q", format)
+}
+
+func ParseImportConflictAction(action string) (ImportConflictAction, error) {
+	switch a := ImportConflictAction(strings.ToLower(action)); a {
+	case "":
+		return ImportConflictFail, nil
+	case ImportConflictFail, ImportConflictSkip, ImportConflictUpdate:
+		return a, nil
+	}
+
+	return "", fmt.Errorf("unknown conflict action %q", action)
+}
+
+var importRowSQL = SqlT(`
+	INSERT INTO {{.TableName}} {{.Columns}}
+	VALUES {{.Values}}
+	{{.OnConflict}}
+	RETURNING (xmax = 0) AS inserted
+`)
+
+// ImportRows inserts rows from CSV or JSON file in a single transaction.
+// Every row runs in its own savepoint, so all errors are reported at once,
+// but nothing is committed if at least one row fails or DryRun is set.
+func (s DataService) ImportRows(tableName string, r io.Reader, options ImportOptions) (*ImportResult, error) {
+	table, err := s.getWritableTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := newImportReader(r, options.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, err := importMapping(table, reader.headers, options.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	// table column order is used for the insert
+	var columns []Column
+	for _, col := range table.Columns {
+		for _, target := range mapping {
+			if target == col.Name {
+				columns = append(columns, col)
+				break
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.New("can't import rows with zero mapped columns")
+	}
+
+	// validates conflict action before reading rows
+	if _, err := importOnConflictSQL(table, columns, options.OnConflict); err != nil {
+		return nil, err
+	}
+
+	// JSON rows can miss some mapped keys, so statements are built per set of present columns
+	statements := make(map[string]string)
+
+	statement := func(columns []Column) (string, error) {
+		insertColumns := make([]string, len(columns))
+		insertValues := make([]string, len(columns))
+		for i, col := range columns {
+			insertColumns[i] = col.SafeName()
+			// explicit cast, so postgres coerces text values by the column type
+			insertValues[i] = fmt.Sprintf("CAST($%d AS %s)", i+1, col.RegType)
+		}
+
+		key := strings.Join(insertColumns, ",")
+		if sql, ok := statements[key]; ok {
+			return sql, nil
+		}
+
+		onConflict, err := importOnConflictSQL(table, columns, options.OnConflict)
+		if err != nil {
+			return "", err
+		}
+
+		statements[key] = importRowSQL.Exec(map[string]any{
+			"TableName":  table.SafeName(),
+			"Columns":    fmt.Sprintf("(%s)", key),
+			"Values":     fmt.Sprintf("(%s)", strings.Join(insertValues, ",")),
+			"OnConflict": onConflict,
+		})
+
+		return statements[key], nil
+	}
+
+	ctx := context.Background()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	result := &ImportResult{DryRun: options.DryRun}
+
+	addError := func(row int, err error) {
+		result.Failed += 1
+		if len(result.Errors) < MaxImportReportedErrors {
+			result.Errors = append(result.Errors, BulkRowError{Row: row, Error: err.Error()})
+		}
+	}
+
+	for {
+		record, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		index := result.Total
+		result.Total += 1
+
+		if err != nil {
+			if errors.Is(err, errImportRowSkip) {
+				addError(index, err)
+				continue
+			}
+			return nil, err
+		}
+
+		rowColumns, args, err := importRowArgs(columns, mapping, record)
+		if err != nil {
+			addError(index, err)
+			continue
+		}
+
+		sql, err := statement(rowColumns)
+		if err != nil {
+			return nil, err
+		}
+
+		inserted, err := importRow(ctx, tx, sql, args)
+
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			result.Skipped += 1
+		case err != nil:
+			addError(index, err)
+		case inserted:
+			result.Inserted += 1
+		default:
+			result.Updated += 1
+		}
+	}
+
+	if options.DryRun || result.Failed > 0 {
+		return result, nil
+	}
+
+	summary := map[string]any{"inserted": result.Inserted, "updated": result.Updated, "skipped": result.Skipped}
+	if err := s.auditSummary(ctx, tx, table, AuditImport, summary); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	result.Committed = true
+	return result, nil
+}
+
+// runs insert inside a savepoint, so failed row doesn't abort the whole transaction
+func importRow(ctx context.Context, tx pgx.Tx, sql string, args []any) (bool, error) {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer savepoint.Rollback(ctx)
+
+	var inserted bool
+	if err := savepoint.QueryRow(ctx, sql, args...).Scan(&inserted); err != nil {
+		return false, err
+	}
+
+	return inserted, savepoint.Commit(ctx)
+}
+
+func importOnConflictSQL(table *Table, columns []Column, action ImportConflictAction) (string, error) {
+	switch action {
+	case ImportConflictSkip:
+		return "ON CONFLICT DO NOTHING", nil
+	case ImportConflictUpdate:
+		var primaryKey []string
+		for _, col := range table.PrimaryKey() {
+			primaryKey = append(primaryKey, col.Name)
+		}
+
+		if len(primaryKey) == 0 {
+			return "", errors.New("can't update rows on conflict, table doesn't have primary key")
+		}
+
+		updateColumns := make([]string, len(columns))
+		for i, col := range columns {
+			updateColumns[i] = col.Name
+		}
+
+		upsert := UpsertOptions{OnConflict: primaryKey, Action: ConflictActionUpdate}
+		return upsert.ToSQL(table, updateColumns)
+	}
+
+	return "", nil
+}
+
+// returns file header -> column name for all used headers
+func importMapping(table *Table, headers []string, mapping map[string]string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	if len(mapping) == 0 {
+		for _, h := range headers {
+			if _, ok := table.GetColumn(h); ok {
+				result[h] = h
+			}
+		}
+
+		return result, nil
+	}
+
+	for header, colName := range mapping {
+		if _, ok := table.GetColumn(colName); !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, colName)
+		}
+
+		result[header] = colName
+	}
+
+	return result, nil
+}
+
+// returns record columns with their values, columns of absent JSON keys are omitted,
+// so they get defaults on insert and keep values on update
+func importRowArgs(columns []Column, mapping map[string]string, record map[string]any) ([]Column, []any, error) {
+	values := make(map[string]any, len(columns))
+	for header, colName := range mapping {
+		if v, ok := record[header]; ok {
+			values[colName] = v
+		}
+	}
+
+	var rowColumns []Column
+	var args []any
+
+	for _, col := range columns {
+		v, ok := values[col.Name]
+		if !ok {
+			continue
+		}
+
+		rowColumns = append(rowColumns, col)
+
+		// empty CSV cells are NULLs for non text columns
+		if s, isString := v.(string); isString && s == "" && !col.IsText {
+			v = nil
+		}
+
+		if v == nil {
+			args = append(args, nil)
+			continue
+		}
+
+		// strings are treated as raw JSON the same way as in InsertRow and bulk insert
+		if s, isString := v.(string); isString && col.IsJSON() {
+			args = append(args, json.RawMessage(s))
+			continue
+		}
+
+		// strings are parsed by postgres as is
+		if s, isString := v.(string); isString {
+			args = append(args, s)
+			continue
+		}
+
+		arg, err := filterArgValue(&col, v)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		args = append(args, arg)
+	}
+
+	if len(rowColumns) == 0 {
+		return nil, nil, errors.New("row doesn't have any mapped columns")
+	}
+
+	return rowColumns, args, nil
+}
+
+var errImportRowSkip = errors.New("invalid row")
+
+// importReader unifies CSV and JSON inputs as header -> value records
+type importReader struct {
+	headers []string
+	next    func() (map[string]any, error)
+}
+
+func newImportReader(r io.Reader, format ImportFormat) (*importReader, error) {
+	if format == ImportFormatJSON {
+		decoder, err := newRowsDecoder(r)
+		if err != nil {
+			return nil, err
+		}
+
+		// JSON rows can have any keys, so headers are taken from the first row
+		first, err := decoder.next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		var headers []string
+		for key := range first {
+			headers = append(headers, key)
+		}
+
+		next := func() (map[string]any, error) {
+			if first != nil {
+				row := first
+				first = nil
+				return row, nil
+			}
+
+			return decoder.next()
+		}
+
+		return &importReader{headers: headers, next: next}, nil
+	}
+
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	headers, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("can't read csv headers: %w", err)
+	}
+	headers = append([]string(nil), headers...)
+
+	next := func() (map[string]any, error) {
+		record, err := cr.Read()
+
+		if errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("%w: expected %d fields, got %d", errImportRowSkip, len(headers), len(record))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]any, len(headers))
+		for i, h := range headers {
+			row[h] = record[i]
+		}
+
+		return row, nil
+	}
+
+	return &importReader{headers: headers, next: next}, nil
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestImportRowArgs(t *testing.T) {
+	table := testTable()
+	mapping := map[string]string{"ID": "id", "Title": "title", "Price": "price", "Tags": "tags", "Meta": "meta"}
+
+	tests := []struct {
+		name    string
+		record  map[string]any
+		columns []string
+		args    []any
+		err     bool
+	}{
+		{
+			name:    "csv strings",
+			record:  map[string]any{"ID": "1", "Title": "", "Price": "", "Tags": "{a,b}"},
+			columns: []string{"id", "title", "price", "tags"},
+			// empty cells are NULLs only for non text columns
+			args: []any{"1", "", nil, "{a,b}"},
+		},
+		{
+			name:    "json values",
+			record:  map[string]any{"ID": json.Number("2"), "Title": "x", "Price": 1.5, "Tags": []any{"a", "b"}},
+			columns: []string{"id", "title", "price", "tags"},
+			args:    []any{"2", "x", "1.5", `{"a","b"}`},
+		},
+		{
+			name:    "json column strings are raw json",
+			record:  map[string]any{"ID": "4", "Meta": `{"a": 1}`},
+			columns: []string{"id", "meta"},
+			args:    []any{"4", json.RawMessage(`{"a": 1}`)},
+		},
+		{
+			name:    "empty json cell is null",
+			record:  map[string]any{"ID": "5", "Meta": ""},
+			columns: []string{"id", "meta"},
+			args:    []any{"5", nil},
+		},
+		{
+			name:    "absent json keys are omitted",
+			record:  map[string]any{"ID": json.Number("3"), "Price": nil},
+			columns: []string{"id", "price"},
+			args:    []any{"3", nil},
+		},
+		{
+			name:   "no mapped keys",
+			record: map[string]any{"Other": "x"},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, args, err := importRowArgs(table.Columns, mapping, tt.record)
+
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, col := range columns {
+				names = append(names, col.Name)
+			}
+
+			if !reflect.DeepEqual(names, tt.columns) {
+				t.Errorf("columns = %v, want %v", names, tt.columns)
+			}
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestImportOnConflictSQL(t *testing.T) {
+	table := testTable()
+	columns := table.GetColumns([]string{"id", "price"})
+
+	tests := []struct {
+		action ImportConflictAction
+		sql    string
+	}{
+		{ImportConflictFail, ""},
+		{ImportConflictSkip, "ON CONFLICT DO NOTHING"},
+		// only present columns are updated, primary key is the conflict target
+		{ImportConflictUpdate, `ON CONFLICT ("id") DO UPDATE SET "price" = EXCLUDED."price"`},
+	}
+
+	for _, tt := range tests {
+		sql, err := importOnConflictSQL(table, columns, tt.action)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.action, err)
+		}
+
+		if sql != tt.sql {
+			t.Errorf("%s: sql = %q, want %q", tt.action, sql, tt.sql)
+		}
+	}
+}
+
+func TestParseImportFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		fileName string
+		want     ImportFormat
+		err      bool
+	}{
+		{"", "rows.csv", ImportFormatCSV, false},
+		{"", "rows.ndjson", ImportFormatJSON, false},
+		{"JSON", "rows.csv", ImportFormatJSON, false},
+		{"", "rows.xml", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseImportFormat(tt.format, tt.fileName)
+
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseImportFormat(%q, %q) = %q, %v", tt.format, tt.fileName, got, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ---------------------- Foreign Key Labels -------------------------------
+
+// virtual label column of the foreign key column, e.g. customer_id:label
+const LabelColumnSuffix = ":label"
+
+func LabelColumnName(colName string) string {
+	return colName + LabelColumnSuffix
+}
+
+// returns display expression of the referenced table from its settings, empty if not configured.
+// Raw SQL expression is used only when raw SQL filters are allowed, display column otherwise.
+func (s DataService) displaySQL(table *Table) string {
+	settings, err := s.schema.GetTableSettings(table.Key)
+	if err != nil {
+		return ""
+	}
+
+	if len(settings.DisplayExpression) > 0 && s.AllowRawSQLFilters {
+		return settings.DisplayExpression
+	}
+
+	if col, ok := table.GetColumn(settings.DisplayColumn); ok {
+		return col.SafeName()
+	}
+
+	return ""
+}
+
+// labeledTable returns a copy of the table extended with virtual label columns
+// for foreign keys to tables with configured display column or expression.
+// Labels can be selected, filtered and sorted the same way as regular columns.
+func (s DataService) labeledTable(table *Table) *Table {
+	var labelExps []string
+	var labelColumns []Column
+	labeledColumns := make(map[string]bool)
+
+	for _, fk := range table.ForeignKeys {
+		// composite key gets a single label on its last column not labeled yet,
+		// columns shared by several keys (e.g. tenant_id) usually go first
+		var col *Column
+		for i := len(fk.Columns) - 1; i >= 0; i-- {
+			if !labeledColumns[fk.Columns[i].Name] {
+				col = &fk.Columns[i]
+				break
+			}
+		}
+
+		if col == nil {
+			continue
+		}
+
+		refTable, err := s.schema.GetTable(fk.TableKey())
+		if err != nil {
+			continue
+		}
+
+		display := s.displaySQL(refTable)
+		if display == "" {
+			continue
+		}
+
+		labeledColumns[col.Name] = true
+
+		refColumns := fk.ReferencedColumns()
+		conds := make([]string, len(fk.Columns))
+		for i, keyCol := range fk.Columns {
+			conds[i] = fmt.Sprintf(`"__pgpanel_ref"."%s" = "__pgpanel_main".%s`, refColumns[i], keyCol.SafeName())
+		}
+
+		name := LabelColumnName(col.Name)
+
+		// subquery resolves unqualified names of the expression to the referenced table
+		labelExps = append(labelExps, fmt.Sprintf(
+			`(SELECT (%s)::text FROM %s AS "__pgpanel_ref" WHERE %s LIMIT 1) AS "%s"`,
+			display, refTable.SafeName(), strings.Join(conds, " AND "), name,
+		))
+
+		labelColumns = append(labelColumns, Column{
+			Name:       name,
+			OID:        pgtype.TextOID,
+			RegType:    "text",
+			UdtName:    "text",
+			IsText:     true,
+			IsNullable: true,
+		})
+	}
+
+	if len(labelExps) == 0 {
+		return table
+	}
+
+	// xmin is kept for row versions, views don't have it
+	mainColumns := `"__pgpanel_main".*`
+	if table.Kind != TableKindView {
+		mainColumns = `"__pgpanel_main".xmin, ` + mainColumns
+	}
+
+	labeled := *table
+	labeled.Columns = append(slices.Clone(table.Columns), labelColumns...)
+	labeled.source = fmt.Sprintf(
+		`(SELECT %s, %s FROM %s AS "__pgpanel_main") AS "%s"`,
+		mainColumns, strings.Join(labelExps, ", "), table.SafeName(), table.Name,
+	)
+
+	return &labeled
+}
+
+// adds label columns of selected foreign key columns
+func withLabelColumns(table *Table, columns SelectColumns) SelectColumns {
+	if columns.IsEmpty() {
+		return columns
+	}
+
+	res := slices.Clone(columns)
+
+	for _, name := range columns {
+		label := LabelColumnName(name)
+
+		if _, ok := table.GetColumn(label); ok && !slices.Contains(res, label) {
+			res = append(res, label)
+		}
+	}
+
+	return res
+}
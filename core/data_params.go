@@ -1,8 +1,12 @@
 package core
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -12,11 +16,18 @@ const (
 	TextFiltersQK     = "textFilters"
 	TextFiltersColsQK = "textFiltersCols"
 	FiltersQK         = "filters"
+	WhereQK           = "where"
 	FiltersArgsQK     = "filtersArgs"
 	OffsetQK          = "offset"
 	LimitQK           = "limit"
+	CursorQK          = "cursor"
 	SortQK            = "sort"
 	FormViewModeQK    = "mode"
+	CountModeQK       = "count"
+	LabelsQK          = "labels"
+	WithDeletedQK     = "withDeleted"
+	OnConflictQK      = "onConflict"
+	ConflictActionQK  = "action"
 
 	QueryArgsDelimiter = "|"
 
@@ -27,17 +38,35 @@ const (
 
 	InsertMode FormViewMode = "insert"
 	UpdateMode FormViewMode = "update"
+
+	CountModeAuto      CountMode = "auto"
+	CountModeExact     CountMode = "exact"
+	CountModeEstimated CountMode = "estimated"
+	CountModeNone      CountMode = "none"
+
+	ConflictActionUpdate ConflictAction = "update"
+	ConflictActionIgnore ConflictAction = "ignore"
 )
 
 // ---------------------- General Filters Interface -------------------------------
 
 type Filters struct {
 	TextSearch *TextSearchFilters
+	Structured *StructuredFilters
 	SQL        *SQLFilters
+	// include soft-deleted rows of tables with soft delete configured
+	WithDeleted bool
 }
 
 // Parse Filters
-func ParseFiltersFromQuery(q url.Values) Filters {
+func ParseFiltersFromQuery(q url.Values) (Filters, error) {
+	filters, err := parseFiltersFromQuery(q)
+	filters.WithDeleted = q.Get(WithDeletedQK) == "true"
+
+	return filters, err
+}
+
+func parseFiltersFromQuery(q url.Values) (Filters, error) {
 	textFilters := q.Get(TextFiltersQK)
 
 	if len(textFilters) > 0 {
@@ -46,17 +75,27 @@ func ParseFiltersFromQuery(q url.Values) Filters {
 			cols = strings.Split(colsRaw, QueryArgsDelimiter)
 		}
 
-		return Filters{TextSearch: &TextSearchFilters{Text: textFilters, Cols: cols}, SQL: nil}
+		return Filters{TextSearch: &TextSearchFilters{Text: textFilters, Cols: cols}}, nil
+	}
+
+	if where := q.Get(WhereQK); len(where) > 0 {
+		structured, err := ParseStructuredFilters(where)
+		if err != nil {
+			return Filters{}, err
+		}
+
+		return Filters{Structured: &structured}, nil
 	}
 
 	filters := q.Get(FiltersQK)
 	rawArgs := q.Get(FiltersArgsQK)
 
-	sql := ParseSQLFilters(filters, rawArgs)
-	return Filters{
-		SQL:        &sql,
-		TextSearch: nil,
+	if len(filters) == 0 {
+		return Filters{}, nil
 	}
+
+	sql := ParseSQLFilters(filters, rawArgs)
+	return Filters{SQL: &sql}, nil
 }
 
 func ParseSQLFilters(statement string, rawArgs string) SQLFilters {
@@ -74,16 +113,26 @@ func ParseSQLFilters(statement string, rawArgs string) SQLFilters {
 	return SQLFilters{Statement: statement, Args: args}
 }
 
-func (f Filters) ToSQL(t *Table) (string, []any) {
+func (f Filters) IsRawSQL() bool {
+	return f.SQL != nil && len(f.SQL.Statement) > 0
+}
+
+func (f Filters) ToSQL(t *Table) (string, []any, error) {
 	if f.TextSearch != nil {
-		return f.TextSearch.ToSQL(t)
+		sql, args := f.TextSearch.ToSQL(t)
+		return sql, args, nil
+	}
+
+	if f.Structured != nil {
+		return f.Structured.ToSQL(t)
 	}
 
 	if f.SQL != nil {
-		return f.SQL.ToSQL(t)
+		sql, args := f.SQL.ToSQL(t)
+		return sql, args, nil
 	}
 
-	return "", nil // No filters set
+	return "", nil, nil // No filters set
 }
 
 // ---------------------- SQLFilters -------------------------------
@@ -103,38 +152,141 @@ func (f SQLFilters) ToSQL(t *Table) (string, []any) {
 
 // ---------------------- TextSearchFilters -------------------------------
 
+type TextSearchMode string
+
+const (
+	// detect mode from table indexes and installed extensions
+	TextSearchModeAuto     TextSearchMode = ""
+	TextSearchModeILike    TextSearchMode = "ilike"
+	TextSearchModeFullText TextSearchMode = "fulltext"
+	TextSearchModeTrigram  TextSearchMode = "trigram"
+)
+
 type TextSearchFilters struct {
 	Text string
 	Cols []string
-}
 
-func (f TextSearchFilters) ToSQL(t *Table) (string, []any) {
-	var filterCols []Column
-	var textColsExps []string
+	// fields below are resolved by DataService, ILIKE is used when Mode is empty
+	Mode TextSearchMode
+	// text search config (e.g. english), database default is used when empty
+	Config string
+	// tsvector expression for full-text mode, built from Cols when nil
+	Vector *TextSearchVector
+	// schema of pg_trgm extension
+	TrigramSchema string
+}
 
+func (f TextSearchFilters) columns(t *Table) []Column {
 	// use specifed cols or by default use all text cols
 	if len(f.Cols) > 0 {
-		filterCols = t.GetColumns(f.Cols)
-	} else {
-		for _, col := range t.Columns {
-			if col.IsText {
-				filterCols = append(filterCols, col)
-			}
+		return t.GetColumns(f.Cols)
+	}
+
+	return t.GetTextColumns()
+}
+
+func (f TextSearchFilters) ToSQL(t *Table) (string, []any) {
+	if f.Mode == TextSearchModeFullText {
+		vector := f.vectorSQL(t)
+		if vector == "" {
+			return "WHERE FALSE", nil
 		}
+
+		return fmt.Sprintf("WHERE %s @@ %s", vector, f.querySQL()), []any{f.Text}
+	}
+
+	var textColsExps []string
+
+	// trigram mode uses raw text for similarity ranking, so pattern is built in SQL
+	pattern, arg := "$1", "%"+strings.ToLower(f.Text)+"%"
+	if f.Mode == TextSearchModeTrigram {
+		pattern, arg = "'%' || $1::text || '%'", f.Text
 	}
 
-	for _, col := range filterCols {
-		textColsExps = append(textColsExps, fmt.Sprintf(`"%s"::text ILIKE $1`, col.Name))
+	for _, col := range f.columns(t) {
+		textColsExps = append(textColsExps, fmt.Sprintf(`"%s"::text ILIKE %s`, col.Name, pattern))
 	}
 
 	if len(textColsExps) == 0 {
 		return "WHERE FALSE", nil
 	}
 
-	pattern := "%" + strings.ToLower(f.Text) + "%"
 	sql := "WHERE " + strings.Join(textColsExps, " OR ")
 
-	return sql, []any{pattern}
+	return sql, []any{arg}
+}
+
+// autoMode detects mode from table indexes: full-text for tsvector column or index,
+// trigram when all searched columns have pg_trgm index, ILIKE otherwise
+func (f TextSearchFilters) autoMode(t *Table) TextSearchMode {
+	if f.Vector != nil {
+		return TextSearchModeFullText
+	}
+
+	columns := f.columns(t)
+
+	hasTrigramIndexes := f.TrigramSchema != "" && len(columns) > 0 && !slices.ContainsFunc(columns, func(col Column) bool {
+		return !slices.Contains(t.TrigramColumns, col.Name)
+	})
+
+	if hasTrigramIndexes {
+		return TextSearchModeTrigram
+	}
+
+	return TextSearchModeILike
+}
+
+// RankSQL returns relevance expression (higher is better), empty for ILIKE mode
+func (f TextSearchFilters) RankSQL(t *Table) string {
+	switch f.Mode {
+	case TextSearchModeFullText:
+		if vector := f.vectorSQL(t); vector != "" {
+			return fmt.Sprintf("ts_rank(%s, %s)", vector, f.querySQL())
+		}
+	case TextSearchModeTrigram:
+		var similarities []string
+		for _, col := range f.columns(t) {
+			similarities = append(similarities, fmt.Sprintf(`"%s".similarity("%s"::text, $1::text)`, f.TrigramSchema, col.Name))
+		}
+
+		if len(similarities) > 0 {
+			return "GREATEST(" + strings.Join(similarities, ", ") + ")"
+		}
+	}
+
+	return ""
+}
+
+func (f TextSearchFilters) querySQL() string {
+	if f.Config == "" {
+		return "websearch_to_tsquery($1::text)"
+	}
+
+	return fmt.Sprintf("websearch_to_tsquery('%s'::regconfig, $1::text)", strings.ReplaceAll(f.Config, "'", "''"))
+}
+
+func (f TextSearchFilters) vectorSQL(t *Table) string {
+	if f.Vector != nil {
+		return f.Vector.Expression
+	}
+
+	// there is no index to use, so every row is converted on the fly
+	var parts []string
+	for _, col := range f.columns(t) {
+		parts = append(parts, fmt.Sprintf(`coalesce("%s"::text, '')`, col.Name))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	document := strings.Join(parts, " || ' ' || ")
+
+	if f.Config == "" {
+		return fmt.Sprintf("to_tsvector(%s)", document)
+	}
+
+	return fmt.Sprintf("to_tsvector('%s'::regconfig, %s)", strings.ReplaceAll(f.Config, "'", "''"), document)
 }
 
 // ---------------------- Select Columns -------------------------------
@@ -163,8 +315,8 @@ func (c SelectColumns) IsEmpty() bool {
 	return len(c) == 0
 }
 
-// ToSQL validates against the schema and returns the SELECT clause
-func (c SelectColumns) ToSQL(t *Table) string {
+// ToColumns validates against the schema and returns selected table columns
+func (c SelectColumns) ToColumns(t *Table) []Column {
 	cols := t.GetColumns(c)
 
 	// use all if empty
@@ -172,9 +324,14 @@ func (c SelectColumns) ToSQL(t *Table) string {
 		cols = t.Columns
 	}
 
+	return cols
+}
+
+// ToSQL validates against the schema and returns the SELECT clause
+func (c SelectColumns) ToSQL(t *Table) string {
 	var safeNames []string
 
-	for _, col := range cols {
+	for _, col := range c.ToColumns(t) {
 		safeNames = append(safeNames, col.SafeName())
 	}
 
@@ -186,6 +343,8 @@ func (c SelectColumns) ToSQL(t *Table) string {
 type Pagination struct {
 	Limit  int
 	Offset int
+	// opaque cursor for keyset pagination, Offset is ignored when set
+	Cursor string
 }
 
 // Parse Pagination
@@ -203,7 +362,41 @@ func ParsePaginationFromQuery(q url.Values) Pagination {
 		limit = DefaultPaginationLimit
 	}
 
-	return Pagination{Offset: offset, Limit: limit}
+	return Pagination{Offset: offset, Limit: limit, Cursor: q.Get(CursorQK)}
+}
+
+// ---------------------- Cursor -------------------------------
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points to a boundary row for keyset pagination.
+// Values are the row values of keyset sorting fields (sorting + primary key).
+type Cursor struct {
+	Values   []any  `json:"v"`
+	Backward bool   `json:"b,omitempty"`
+	Sorting  string `json:"s"`
+}
+
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(raw string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(b)))
+	decoder.UseNumber()
+
+	var c Cursor
+	if err := decoder.Decode(&c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
 }
 
 // ---------------------- Sorting -------------------------------
@@ -243,23 +436,163 @@ func ParseSortingFromQuery(q url.Values) Sorting {
 }
 
 func DefaultTableSorting(table *Table) Sorting {
-	var f SortingField
+	var fields []SortingField
+
+	for _, col := range table.PrimaryKey() {
+		fields = append(fields, SortingField{Name: col.Name, Order: SortingOrderASC})
+	}
+
+	// handle edge case when table doesn't have primary keys
+	if len(fields) == 0 {
+		return Sorting{}
+	}
+
+	return Sorting{Fields: fields}
+}
+
+// KeysetSorting validates sorting fields and appends primary key columns,
+// so every row gets a unique position. Returns false if table has no primary key.
+func KeysetSorting(table *Table, s Sorting) (Sorting, bool, error) {
+	var hasPrimaryKey bool
+	fields := make([]SortingField, 0, len(s.Fields))
+
+	for _, f := range s.Fields {
+		if _, ok := table.GetColumn(f.Name); !ok {
+			return Sorting{}, false, fmt.Errorf("%w: %q", ErrUnknownColumn, f.Name)
+		}
+		fields = append(fields, f)
+	}
+
+	for _, col := range table.PrimaryKey() {
+		hasPrimaryKey = true
+
+		if !slices.ContainsFunc(fields, func(f SortingField) bool { return f.Name == col.Name }) {
+			fields = append(fields, SortingField{Name: col.Name, Order: SortingOrderASC})
+		}
+	}
+
+	return Sorting{Fields: fields}, hasPrimaryKey, nil
+}
+
+// Reverse flips order of all fields, used to fetch previous page
+func (s Sorting) Reverse() Sorting {
+	fields := make([]SortingField, len(s.Fields))
 
-	for _, col := range table.Columns {
-		if col.IsPrimaryKey {
-			f.Name = col.Name
+	for i, f := range s.Fields {
+		f.Order = SortingOrderDESC
+		if s.Fields[i].Order == SortingOrderDESC {
 			f.Order = SortingOrderASC
+		}
+		fields[i] = f
+	}
+
+	return Sorting{Fields: fields}
+}
+
+// String returns sorting in the same format as the sort query param
+func (s Sorting) String() string {
+	names := make([]string, len(s.Fields))
 
-			break
+	for i, f := range s.Fields {
+		names[i] = f.Name
+		if f.Order == SortingOrderDESC {
+			names[i] = "-" + f.Name
 		}
 	}
 
-	// handle edge case when table doesn't have primary keys
-	if len(f.Name) == 0 {
-		return Sorting{}
+	return strings.Join(names, QueryArgsDelimiter)
+}
+
+// KeysetSQL builds predicate that selects rows after the cursor
+// e.g. ("a", "b") > ($1, $2) for the same fields order and not null columns.
+// NULLs follow the postgres default order: last for ASC and first for DESC.
+func (s Sorting) KeysetSQL(t *Table, c *Cursor, paramsOffset int) (string, []any, error) {
+	if len(c.Values) != len(s.Fields) {
+		return "", nil, ErrInvalidCursor
+	}
+
+	var args []any
+	// conditions for the row being equal to the cursor and after it by a single field
+	eqs := make([]string, len(s.Fields))
+	afters := make([]string, len(s.Fields))
+
+	rowComparison := true
+	names := make([]string, len(s.Fields))
+	params := make([]string, len(s.Fields))
+
+	for i, f := range s.Fields {
+		col, ok := t.GetColumn(f.Name)
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %q", ErrUnknownColumn, f.Name)
+		}
+
+		name := col.SafeName()
+		desc := f.Order == SortingOrderDESC
+		names[i] = name
+
+		if c.Values[i] == nil {
+			rowComparison = false
+			eqs[i] = name + " IS NULL"
+
+			// nothing goes after NULLs in ASC order, in DESC all values go after NULLs
+			if desc {
+				afters[i] = name + " IS NOT NULL"
+			}
+			continue
+		}
+
+		arg, err := filterArgValue(col, c.Values[i])
+		if err != nil {
+			return "", nil, err
+		}
+
+		args = append(args, arg)
+		param := fmt.Sprintf("$%d", len(args)+paramsOffset)
+		params[i] = param
+
+		eqs[i] = fmt.Sprintf("%s = %s", name, param)
+
+		if desc {
+			afters[i] = fmt.Sprintf("%s < %s", name, param)
+		} else if col.IsNullable {
+			afters[i] = fmt.Sprintf("(%s > %s OR %s IS NULL)", name, param, name)
+			rowComparison = false
+		} else {
+			afters[i] = fmt.Sprintf("%s > %s", name, param)
+		}
+
+		if s.Fields[0].Order != f.Order {
+			rowComparison = false
+		}
+	}
+
+	// row values comparison can be used only for not null values with the same order
+	if rowComparison {
+		op := ">"
+		if s.Fields[0].Order == SortingOrderDESC {
+			op = "<"
+		}
+
+		sql := fmt.Sprintf("(%s) %s (%s)", strings.Join(names, ", "), op, strings.Join(params, ", "))
+		return sql, args, nil
+	}
+
+	// otherwise expand to (a > $1) OR (a = $1 AND b < $2) OR ...
+	var conds []string
+	for i := range s.Fields {
+		if afters[i] == "" {
+			continue
+		}
+
+		parts := append(slices.Clone(eqs[:i]), afters[i])
+		conds = append(conds, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	if len(conds) == 0 {
+		return "FALSE", args, nil
 	}
 
-	return Sorting{Fields: []SortingField{f}}
+	return "(" + strings.Join(conds, " OR ") + ")", args, nil
 }
 
 func (s Sorting) IsEmpty() bool {
@@ -284,6 +617,17 @@ func (s Sorting) ToSQL() string {
 	return sql
 }
 
+// ---------------------- Where helpers -------------------------------
+
+// AndWhere appends a condition to an existing WHERE clause (may be empty)
+func AndWhere(where string, cond string) string {
+	if len(where) == 0 {
+		return "WHERE " + cond
+	}
+
+	return fmt.Sprintf("WHERE (%s) AND (%s)", strings.TrimPrefix(where, "WHERE "), cond)
+}
+
 // ---------------------- Custom utility types -------------------------------
 type SortingOrder string
 
@@ -299,6 +643,85 @@ func ParseFormViewModeFromQuery(q url.Values) FormViewMode {
 	return InsertMode
 }
 
+// CountMode defines how total rows are counted.
+// Auto uses exact count for small tables and estimation for huge ones.
+type CountMode string
+
+func ParseCountModeFromQuery(q url.Values) CountMode {
+	switch mode := CountMode(strings.ToLower(q.Get(CountModeQK))); mode {
+	case CountModeExact, CountModeEstimated, CountModeNone:
+		return mode
+	}
+
+	return CountModeAuto
+}
+
+// ---------------------- Upsert -------------------------------
+
+type ConflictAction string
+
+type UpsertOptions struct {
+	// conflict target, has to match primary key or unique constraint
+	OnConflict []string
+	Action     ConflictAction
+}
+
+// Parse UpsertOptions: ?onConflict=col1|col2&action=update|ignore
+// returns nil if onConflict is not set
+func ParseUpsertOptionsFromQuery(q url.Values) (*UpsertOptions, error) {
+	raw := q.Get(OnConflictQK)
+	if raw == "" {
+		return nil, nil
+	}
+
+	options := UpsertOptions{
+		OnConflict: strings.Split(raw, QueryArgsDelimiter),
+		Action:     ConflictAction(strings.ToLower(q.Get(ConflictActionQK))),
+	}
+
+	switch options.Action {
+	case "":
+		options.Action = ConflictActionUpdate
+	case ConflictActionUpdate, ConflictActionIgnore:
+	default:
+		return nil, fmt.Errorf("unknown conflict action %q", options.Action)
+	}
+
+	return &options, nil
+}
+
+// ToSQL validates conflict target and returns ON CONFLICT clause,
+// updateColumns are set from EXCLUDED row (target columns are skipped)
+func (o UpsertOptions) ToSQL(t *Table, updateColumns []string) (string, error) {
+	if len(o.OnConflict) == 0 {
+		return "", errors.New("empty conflict target")
+	}
+
+	if !t.IsUniqueKey(o.OnConflict) {
+		return "", fmt.Errorf("conflict target (%s) doesn't match primary key or unique constraint", strings.Join(o.OnConflict, ", "))
+	}
+
+	var target []string
+	for _, col := range t.GetColumns(o.OnConflict) {
+		target = append(target, col.SafeName())
+	}
+
+	var updates []string
+	for _, col := range t.GetColumns(updateColumns) {
+		if slices.Contains(o.OnConflict, col.Name) {
+			continue
+		}
+
+		updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", col.SafeName(), col.SafeName()))
+	}
+
+	if o.Action == ConflictActionIgnore || len(updates) == 0 {
+		return fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", strings.Join(target, ", ")), nil
+	}
+
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(target, ", "), strings.Join(updates, ", ")), nil
+}
+
 // ---------------------- Composite types -------------------------------
 
 type GetRowsParams struct {
@@ -306,6 +729,9 @@ type GetRowsParams struct {
 	Filters       Filters
 	Pagination    Pagination
 	Sorting       Sorting
+	CountMode     CountMode
+	// add foreign keys display labels as virtual <column>:label columns
+	WithLabels bool
 }
 
 func DefaultGetRowsParams() *GetRowsParams {
@@ -314,15 +740,23 @@ func DefaultGetRowsParams() *GetRowsParams {
 		Filters:       Filters{},
 		SelectColumns: SelectColumns{},
 		Sorting:       Sorting{},
+		CountMode:     CountModeAuto,
 	}
 }
 
 // Parse GetRowsParams with combined ParseFiltersFromQuery, ParsePaginationFromQuery, ParseSortingFromQuery
-func ParseGetRowsParamsFromQuery(q url.Values) GetRowsParams {
+func ParseGetRowsParamsFromQuery(q url.Values) (GetRowsParams, error) {
+	filters, err := ParseFiltersFromQuery(q)
+	if err != nil {
+		return GetRowsParams{}, err
+	}
+
 	return GetRowsParams{
-		Filters:       ParseFiltersFromQuery(q),
+		Filters:       filters,
 		SelectColumns: ParseSelectColumnsFromQuery(q),
 		Pagination:    ParsePaginationFromQuery(q),
 		Sorting:       ParseSortingFromQuery(q),
-	}
+		CountMode:     ParseCountModeFromQuery(q),
+		WithLabels:    q.Get(LabelsQK) == "true",
+	}, nil
 }
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	c := Cursor{Values: []any{"bob", json.Number("10"), nil}, Backward: true, Sorting: "-name|id"}
+
+	decoded, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if !reflect.DeepEqual(*decoded, c) {
+		t.Errorf("decoded = %#v, want %#v", *decoded, c)
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	for _, raw := range []string{"not base64!", "bm90IGpzb24"} {
+		if _, err := DecodeCursor(raw); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%q: expected ErrInvalidCursor, got %v", raw, err)
+		}
+	}
+}
+
+func TestKeysetSorting(t *testing.T) {
+	table := testTable()
+
+	sorting, hasPrimaryKey, err := KeysetSorting(table, Sorting{Fields: []SortingField{{Name: "name", Order: SortingOrderDESC}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !hasPrimaryKey || sorting.String() != "-name|id" {
+		t.Errorf("got %q (pk %v), want -name|id with pk", sorting.String(), hasPrimaryKey)
+	}
+
+	if sorting.Reverse().String() != "name|-id" {
+		t.Errorf("reversed = %q, want name|-id", sorting.Reverse().String())
+	}
+
+	if _, _, err := KeysetSorting(table, Sorting{Fields: []SortingField{{Name: "nope"}}}); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn, got %v", err)
+	}
+}
+
+func TestKeysetSQL(t *testing.T) {
+	asc := func(name string) SortingField { return SortingField{Name: name, Order: SortingOrderASC} }
+	desc := func(name string) SortingField { return SortingField{Name: name, Order: SortingOrderDESC} }
+
+	tests := []struct {
+		name   string
+		fields []SortingField
+		values []any
+		offset int
+		sql    string
+		args   []any
+		err    error
+	}{
+		{
+			name:   "row comparison",
+			fields: []SortingField{asc("name"), asc("id")},
+			values: []any{"bob", json.Number("10")},
+			sql:    `("name", "id") > ($1, $2)`,
+			args:   []any{"bob", "10"},
+		},
+		{
+			name:   "row comparison desc with offset",
+			fields: []SortingField{desc("name"), desc("id")},
+			values: []any{"bob", json.Number("10")},
+			offset: 2,
+			sql:    `("name", "id") < ($3, $4)`,
+			args:   []any{"bob", "10"},
+		},
+		{
+			name:   "mixed order",
+			fields: []SortingField{desc("name"), asc("id")},
+			values: []any{"bob", json.Number("10")},
+			sql:    `(("name" < $1) OR ("name" = $1 AND "id" > $2))`,
+			args:   []any{"bob", "10"},
+		},
+		{
+			name:   "nullable asc includes nulls",
+			fields: []SortingField{asc("email"), asc("id")},
+			values: []any{"a@b.c", json.Number("10")},
+			sql:    `((("email" > $1 OR "email" IS NULL)) OR ("email" = $1 AND "id" > $2))`,
+			args:   []any{"a@b.c", "10"},
+		},
+		{
+			name:   "null value asc",
+			fields: []SortingField{asc("email"), asc("id")},
+			values: []any{nil, json.Number("10")},
+			sql:    `(("email" IS NULL AND "id" > $1))`,
+			args:   []any{"10"},
+		},
+		{
+			name:   "null value desc",
+			fields: []SortingField{desc("email"), asc("id")},
+			values: []any{nil, json.Number("10")},
+			sql:    `(("email" IS NOT NULL) OR ("email" IS NULL AND "id" > $1))`,
+			args:   []any{"10"},
+		},
+		{
+			name:   "values count mismatch",
+			fields: []SortingField{asc("id")},
+			values: []any{json.Number("1"), json.Number("2")},
+			err:    ErrInvalidCursor,
+		},
+		{
+			name:   "unknown column",
+			fields: []SortingField{asc("nope")},
+			values: []any{json.Number("1")},
+			err:    ErrUnknownColumn,
+		},
+	}
+
+	table := testTable()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorting := Sorting{Fields: tt.fields}
+			sql, args, err := sorting.KeysetSQL(table, &Cursor{Values: tt.values}, tt.offset)
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if sql != tt.sql {
+				t.Errorf("sql = %s, want %s", sql, tt.sql)
+			}
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ---------------------- Referencing Rows (reverse relations) -------------------------------
+
+const ReferencingTableQK = "referencingTable"
+
+var ErrRowNotFound = errors.New("row not found")
+
+type ReferencingRows struct {
+	TableReference
+	Rows       json.RawMessage `json:"rows"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+	PrevCursor string          `json:"prevCursor,omitempty"`
+	Total      *RowsCount      `json:"total,omitempty"`
+}
+
+// GetReferencingRows finds the row by filters and returns a page of child rows
+// from every table referencing it (e.g. orders and invoices of a customer).
+// Cursors, offsets, sorting and selected columns belong to a single child table,
+// so they are accepted only together with referencingTable, which pages that table alone.
+func (s DataService) GetReferencingRows(tableName string, rowFilters Filters, params GetRowsParams, referencingTable string) ([]ReferencingRows, error) {
+	table, err := s.schema.GetTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	childParams, err := referencingRowsParams(params, referencingTable)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := s.schema.GetReferencingTables(table.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	if referencingTable != "" {
+		refTable, err := s.schema.GetTable(referencingTable)
+		if err != nil {
+			return nil, err
+		}
+
+		var filtered []TableReference
+		for _, ref := range refs {
+			if ref.Table == refTable.Key {
+				filtered = append(filtered, ref)
+			}
+		}
+		refs = filtered
+	}
+
+	where, args, err := s.userFiltersToSQL(table, rowFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(where) == 0 {
+		return nil, errors.New("can't find row with empty filters")
+	}
+
+	where = s.notDeletedSQL(table, rowFilters, where)
+
+	var referencedColumns []string
+	for _, ref := range refs {
+		referencedColumns = append(referencedColumns, ref.ReferencedColumns...)
+	}
+
+	sql := getRowsSQL.Exec(map[string]any{
+		"Select":  SelectColumns(referencedColumns).ToSQL(table),
+		"From":    table.SafeName(),
+		"Where":   where,
+		"OrderBy": "",
+		"Limit":   1,
+		"Offset":  0,
+	})
+
+	raw, err := s.queryAsJsonArray(sql, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []RawRow
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&rows); err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrRowNotFound
+	}
+
+	row := rows[0]
+	result := []ReferencingRows{}
+
+	for _, ref := range refs {
+		childTable, err := s.schema.GetTable(ref.Table)
+		if err != nil {
+			return nil, err
+		}
+
+		childRows := ReferencingRows{TableReference: ref, Rows: json.RawMessage("[]")}
+
+		conds := make([]FilterExpr, len(ref.Columns))
+		hasNull := false
+
+		for i, col := range ref.Columns {
+			value := row[ref.ReferencedColumns[i]]
+			hasNull = hasNull || value == nil
+
+			conds[i] = FilterEq(col, value)
+		}
+
+		// NULL key can't be referenced
+		if hasNull {
+			result = append(result, childRows)
+			continue
+		}
+
+		childParams := childParams
+		childParams.Filters = Filters{Structured: &StructuredFilters{Expr: FilterAnd(conds...)}, WithDeleted: params.Filters.WithDeleted}
+
+		if childParams.Sorting.IsEmpty() {
+			childParams.Sorting = DefaultTableSorting(childTable)
+		}
+
+		page, err := s.getRowsPage(childTable, childParams)
+		if err != nil {
+			return nil, err
+		}
+
+		total, err := s.countRows(childTable, childParams.Filters, childParams.CountMode)
+		if err != nil {
+			return nil, err
+		}
+
+		childRows.Rows = page.Rows
+		childRows.NextCursor = page.NextCursor
+		childRows.PrevCursor = page.PrevCursor
+		childRows.Total = total
+
+		result = append(result, childRows)
+	}
+
+	return result, nil
+}
+
+// returns params applied to child tables, table specific ones require referencingTable
+func referencingRowsParams(params GetRowsParams, referencingTable string) (GetRowsParams, error) {
+	if referencingTable == "" {
+		if params.Pagination.Cursor != "" || params.Pagination.Offset > 0 || !params.Sorting.IsEmpty() || !params.SelectColumns.IsEmpty() {
+			return GetRowsParams{}, fmt.Errorf("cursor, offset, sorting and select require %s", ReferencingTableQK)
+		}
+	}
+
+	return GetRowsParams{
+		SelectColumns: params.SelectColumns,
+		Pagination:    params.Pagination,
+		Sorting:       params.Sorting,
+		CountMode:     params.CountMode,
+		WithLabels:    params.WithLabels,
+	}, nil
+}
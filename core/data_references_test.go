@@ -0,0 +1,39 @@
+package core
+
+import "testing"
+
+func TestReferencingRowsParams(t *testing.T) {
+	sorting := Sorting{Fields: []SortingField{{Name: "created_at", Order: SortingOrderDESC}}}
+
+	tests := []struct {
+		name             string
+		params           GetRowsParams
+		referencingTable string
+		err              bool
+	}{
+		{"first page of every child", GetRowsParams{Pagination: Pagination{Limit: 10}}, "", false},
+		{"cursor without child table", GetRowsParams{Pagination: Pagination{Cursor: "abc"}}, "", true},
+		{"offset without child table", GetRowsParams{Pagination: Pagination{Offset: 10}}, "", true},
+		{"sorting without child table", GetRowsParams{Sorting: sorting}, "", true},
+		{"select without child table", GetRowsParams{SelectColumns: SelectColumns{"id"}}, "", true},
+		{"cursor and sorting of child table", GetRowsParams{Pagination: Pagination{Cursor: "abc"}, Sorting: sorting}, "orders", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			params.Filters = Filters{SQL: &SQLFilters{Statement: "id = 1"}}
+
+			got, err := referencingRowsParams(params, tt.referencingTable)
+
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+
+			// row filters never leak to child tables
+			if err == nil && got.Filters.SQL != nil {
+				t.Errorf("filters = %#v, want empty", got.Filters)
+			}
+		})
+	}
+}
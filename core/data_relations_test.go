@@ -0,0 +1,89 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+// user_roles (tenant_id, user_id) -> users (tenant_id, id), (tenant_id, role_id) -> roles (tenant_id, id)
+func tenantRelationData() *relationData {
+	tenant := Column{Name: "tenant_id", UdtName: "int4"}
+	user := Column{Name: "user_id", UdtName: "int4"}
+	role := Column{Name: "role_id", UdtName: "int4"}
+
+	joinTable := &Table{
+		Key:     "public.user_roles",
+		Name:    "user_roles",
+		Schema:  "public",
+		Columns: []Column{tenant, user, role},
+		ForeignKeys: []ForeignKey{
+			{
+				ConstraintName: "user_roles_role_fkey",
+				TableSchema:    "public",
+				TableName:      "roles",
+				ColumnNames:    []string{"tenant_id", "role_id"},
+				RefColumnNames: []string{"tenant_id", "id"},
+				Columns:        []Column{tenant, role},
+			},
+			{
+				ConstraintName: "user_roles_user_fkey",
+				TableSchema:    "public",
+				TableName:      "users",
+				ColumnNames:    []string{"tenant_id", "user_id"},
+				RefColumnNames: []string{"tenant_id", "id"},
+				Columns:        []Column{tenant, user},
+			},
+		},
+	}
+
+	return &relationData{
+		joinTable:       joinTable,
+		mainJoinKey:     joinTable.GetForeignKeysByTable("public.users")[0],
+		relationJoinKey: joinTable.GetForeignKeysByTable("public.roles")[0],
+	}
+}
+
+func TestGetForeignKeysByTable(t *testing.T) {
+	rd := tenantRelationData()
+
+	if rd.mainJoinKey.ConstraintName != "user_roles_user_fkey" || rd.relationJoinKey.ConstraintName != "user_roles_role_fkey" {
+		t.Fatalf("unexpected keys %s, %s", rd.mainJoinKey.ConstraintName, rd.relationJoinKey.ConstraintName)
+	}
+
+	if fks := rd.joinTable.GetForeignKeysByTable("public.other"); len(fks) != 0 {
+		t.Errorf("expected no keys, got %v", fks)
+	}
+}
+
+func TestRelationLinkSharedColumns(t *testing.T) {
+	rd := tenantRelationData()
+
+	l, err := rd.link(RowKey{"tenant_id": 1, "id": 10}, RowKey{"tenant_id": 1, "id": 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantWhere := `"public"."user_roles"."tenant_id" = $1 AND "public"."user_roles"."user_id" = $2 AND ` +
+		`"public"."user_roles"."tenant_id" = $3 AND "public"."user_roles"."role_id" = $4`
+
+	if l.where != wantWhere {
+		t.Errorf("where = %s, want %s", l.where, wantWhere)
+	}
+
+	// tenant_id is inserted once
+	if l.columns != `"tenant_id", "user_id", "role_id"` || l.values != "$1, $2, $3" {
+		t.Errorf("columns = %s, values = %s", l.columns, l.values)
+	}
+
+	if !reflect.DeepEqual(l.args, []any{"1", "10", "1", "20"}) {
+		t.Errorf("args = %#v", l.args)
+	}
+
+	if !reflect.DeepEqual(l.insertArgs, []any{"1", "10", "20"}) {
+		t.Errorf("insert args = %#v", l.insertArgs)
+	}
+
+	if _, err := rd.link(RowKey{"tenant_id": 1, "id": 10}, RowKey{"tenant_id": 2, "id": 20}); err == nil {
+		t.Error("expected error for different tenant_id values")
+	}
+}
@@ -0,0 +1,335 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ---------------------- Revert Audit Log Changes -------------------------------
+
+var (
+	ErrRevertConflict = errors.New("can't revert change")
+	ErrCannotRevert   = errors.New("change can't be reverted")
+)
+
+type RevertAction string
+
+const (
+	// updated row gets back its old values
+	RevertActionUpdate RevertAction = "update"
+	// deleted row is inserted again
+	RevertActionInsert RevertAction = "insert"
+	// inserted row is deleted
+	RevertActionDelete RevertAction = "delete"
+)
+
+type ColumnChange struct {
+	Column string `json:"column"`
+	From   any    `json:"from"`
+	To     any    `json:"to"`
+}
+
+// RevertPreview shows changes of the current row made by reverting the audit log entry
+type RevertPreview struct {
+	Entry   *AuditEntry     `json:"entry"`
+	Action  RevertAction    `json:"action"`
+	Current json.RawMessage `json:"current"`
+	Changes []ColumnChange  `json:"changes"`
+	// why the change can't be reverted now, e.g. the row was changed again
+	Conflict string `json:"conflict,omitempty"`
+}
+
+type RevertConflictError struct {
+	Preview *RevertPreview
+}
+
+func (e *RevertConflictError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrRevertConflict, e.Preview.Conflict)
+}
+
+func (e *RevertConflictError) Unwrap() error {
+	return ErrRevertConflict
+}
+
+// PreviewRevert checks that the row still matches the after image of the change and returns the diff
+func (s DataService) PreviewRevert(entryID int64) (*RevertPreview, error) {
+	entry, err := queryAuditEntry(context.Background(), s.db, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := s.getWritableTable(entry.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.previewRevert(table, entry)
+}
+
+// RevertChange applies the preview, row is changed only if it still matches the after image.
+// Revert is written to the audit log too, so it can be reverted as well.
+func (s DataService) RevertChange(entryID int64) (json.RawMessage, error) {
+	entry, err := queryAuditEntry(context.Background(), s.db, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := s.getWritableTable(entry.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := s.previewRevert(table, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if preview.Conflict != "" {
+		return nil, &RevertConflictError{Preview: preview}
+	}
+
+	where, args, err := s.rowKeyToSQL(table, entry.RowKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// generated columns can't be set, they are recalculated from the reverted ones
+	generated, err := s.generatedColumns(table)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	for _, change := range preview.Changes {
+		if !slices.Contains(generated, change.Column) {
+			columns = append(columns, fmt.Sprintf(`"%s"`, change.Column))
+		}
+	}
+
+	st := auditedStatement{operation: AuditRevert}
+
+	switch preview.Action {
+	case RevertActionUpdate:
+		if len(columns) == 0 {
+			return json.RawMessage("[]"), nil
+		}
+
+		args = append(args, string(entry.After), string(entry.Before))
+		where = AndWhere(where, fmt.Sprintf(`to_jsonb("%s") = $%d::jsonb`, table.Name, len(args)-1))
+
+		st.sql = fmt.Sprintf(
+			`UPDATE %s SET (%s) = (SELECT %s FROM jsonb_populate_record(NULL::%s, $%d::jsonb)) %s RETURNING *`,
+			table.SafeName(), strings.Join(columns, ", "), strings.Join(columns, ", "), table.SafeName(), len(args), where,
+		)
+		st.where, st.whereArgs = where, args[:len(args)-1]
+
+	case RevertActionDelete:
+		args = append(args, string(entry.After))
+		where = AndWhere(where, fmt.Sprintf(`to_jsonb("%s") = $%d::jsonb`, table.Name, len(args)))
+
+		st.sql, st.args = s.deleteStatement(table, where, args)
+		st.where, st.whereArgs = where, args
+		st.deleted = s.softDelete(table) == nil
+
+	case RevertActionInsert:
+		// identity columns get their old values as well
+		st.sql = fmt.Sprintf(
+			`INSERT INTO %s (%s) OVERRIDING SYSTEM VALUE SELECT %s FROM jsonb_populate_record(NULL::%s, $1::jsonb) ON CONFLICT DO NOTHING RETURNING *`,
+			table.SafeName(), strings.Join(columns, ", "), strings.Join(columns, ", "), table.SafeName(),
+		)
+		st.args = []any{string(entry.Before)}
+	}
+
+	if st.args == nil {
+		st.args = args
+	}
+
+	rows, err := s.queryAudited(table, st)
+	if err != nil {
+		return nil, err
+	}
+
+	// row was changed (or inserted) between preview and revert
+	if isEmptyJsonArray(rows) {
+		preview.Conflict = "row has been changed while reverting"
+		return nil, &RevertConflictError{Preview: preview}
+	}
+
+	return rows, nil
+}
+
+func (s DataService) previewRevert(table *Table, entry *AuditEntry) (*RevertPreview, error) {
+	hasBefore, hasAfter := isJsonObject(entry.Before), isJsonObject(entry.After)
+
+	if entry.Operation == AuditUpdateSettings || !isJsonObject(entry.RowKey) || (!hasBefore && !hasAfter) {
+		return nil, fmt.Errorf("%w: %s of %s has no row images", ErrCannotRevert, entry.Operation, entry.Table)
+	}
+
+	preview := &RevertPreview{Entry: entry, Current: json.RawMessage("null")}
+
+	switch {
+	case hasBefore && hasAfter:
+		preview.Action = RevertActionUpdate
+	case hasBefore:
+		preview.Action = RevertActionInsert
+	default:
+		preview.Action = RevertActionDelete
+	}
+
+	where, args, err := s.rowKeyToSQL(table, entry.RowKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := fmt.Sprintf(
+		`SELECT to_jsonb("%s"), to_jsonb("%s") = $%d::jsonb FROM %s %s LIMIT 1`,
+		table.Name, table.Name, len(args)+1, table.SafeName(), where,
+	)
+
+	after := entry.After
+	if !hasAfter {
+		after = json.RawMessage("null")
+	}
+
+	var current json.RawMessage
+	var matches bool
+
+	rows, err := s.db.Query(context.Background(), sql, append(args, string(after))...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(&current, &matches); err != nil {
+			rows.Close()
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	exists := current != nil
+	if exists {
+		preview.Current = current
+	}
+
+	switch {
+	case preview.Action == RevertActionInsert && exists:
+		preview.Conflict = "row with the same key exists"
+	case preview.Action != RevertActionInsert && !exists:
+		preview.Conflict = "row doesn't exist anymore"
+	case preview.Action != RevertActionInsert && !matches:
+		preview.Conflict = "row has been changed after this change"
+	}
+
+	preview.Changes, err = diffRowImages(table, preview.Current, entry.Before)
+	if err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// returns WHERE clause for primary key values of the audit log entry
+func (s DataService) rowKeyToSQL(table *Table, rowKey json.RawMessage) (string, []any, error) {
+	var key map[string]any
+
+	decoder := json.NewDecoder(strings.NewReader(string(rowKey)))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&key); err != nil {
+		return "", nil, err
+	}
+
+	var conds []FilterExpr
+	for _, col := range table.PrimaryKey() {
+		value, ok := key[col.Name]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: primary key of %s has been changed", ErrCannotRevert, table.Key)
+		}
+		conds = append(conds, FilterEq(col.Name, value))
+	}
+
+	if len(conds) == 0 {
+		return "", nil, fmt.Errorf("%w: %s has no primary key", ErrCannotRevert, table.Key)
+	}
+
+	return s.filtersToSQL(table, Filters{
+		Structured:  &StructuredFilters{Expr: FilterAnd(conds...)},
+		WithDeleted: true,
+	})
+}
+
+// diffRowImages returns changed columns in the table order, null image means the row doesn't exist
+func diffRowImages(table *Table, from json.RawMessage, to json.RawMessage) ([]ColumnChange, error) {
+	var fromRow, toRow map[string]any
+
+	for _, image := range []struct {
+		raw json.RawMessage
+		dst *map[string]any
+	}{{from, &fromRow}, {to, &toRow}} {
+		if !isJsonObject(image.raw) {
+			continue
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(string(image.raw)))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(image.dst); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := []ColumnChange{}
+
+	for _, col := range table.Columns {
+		fromValue, fromOk := fromRow[col.Name]
+		toValue, toOk := toRow[col.Name]
+
+		if !fromOk && !toOk {
+			continue
+		}
+
+		if fromOk && toOk && reflect.DeepEqual(fromValue, toValue) {
+			continue
+		}
+
+		changes = append(changes, ColumnChange{Column: col.Name, From: fromValue, To: toValue})
+	}
+
+	return changes, nil
+}
+
+func isJsonObject(data json.RawMessage) bool {
+	trimmed := strings.TrimSpace(string(data))
+	return strings.HasPrefix(trimmed, "{")
+}
+
+func (s DataService) generatedColumns(table *Table) ([]string, error) {
+	rows, err := s.db.Query(context.Background(), `
+		SELECT attname::text
+		FROM pg_attribute
+		WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped AND attgenerated <> ''
+	`, table.SafeName())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
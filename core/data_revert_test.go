@@ -0,0 +1,81 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffRowImages(t *testing.T) {
+	table := &Table{
+		Key:    "public.users",
+		Name:   "users",
+		Schema: "public",
+		Columns: []Column{
+			{Name: "id", UdtName: "int4", IsPrimaryKey: true},
+			{Name: "name", UdtName: "text", IsText: true},
+			{Name: "meta", UdtName: "jsonb", IsNullable: true},
+			{Name: "score", UdtName: "numeric", IsNullable: true},
+		},
+	}
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []ColumnChange
+	}{
+		{
+			name: "changed columns in table order",
+			from: `{"id": 1, "name": "bob", "meta": {"a": 1}, "score": 1.50}`,
+			to:   `{"score": 2, "name": "alice", "id": 1, "meta": {"a": 1}}`,
+			want: []ColumnChange{
+				{Column: "name", From: "bob", To: "alice"},
+				{Column: "score", From: json.Number("1.50"), To: json.Number("2")},
+			},
+		},
+		{
+			name: "same row",
+			from: `{"id": 1, "name": "bob", "meta": null}`,
+			to:   `{"id": 1, "name": "bob", "meta": null}`,
+			want: []ColumnChange{},
+		},
+		{
+			name: "row doesn't exist",
+			from: `null`,
+			to:   `{"id": 1, "name": "bob"}`,
+			want: []ColumnChange{
+				{Column: "id", To: json.Number("1")},
+				{Column: "name", To: "bob"},
+			},
+		},
+		{
+			name: "deleted row",
+			from: `{"id": 1, "meta": null}`,
+			to:   ``,
+			want: []ColumnChange{
+				{Column: "id", From: json.Number("1")},
+				{Column: "meta"},
+			},
+		},
+		{
+			name: "unknown columns are ignored",
+			from: `{"id": 1, "dropped": 1}`,
+			to:   `{"id": 1, "dropped": 2}`,
+			want: []ColumnChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diffRowImages(table, json.RawMessage(tt.from), json.RawMessage(tt.to))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,24 +1,44 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	DefaultCountEstimateThreshold = 1_000_000
+)
+
 type DataService struct {
+	// allow to pass raw SQL statement as filters (?filters=...)
+	AllowRawSQLFilters bool
+	// tables with more rows than threshold use estimated count in auto mode
+	CountEstimateThreshold int64
+
 	db     *pgxpool.Pool
 	schema *SchemaService
 	logger *slog.Logger
+	// admin recorded in the audit log, see WithAdmin
+	admin string
 }
 
 func NewDataService(db *pgxpool.Pool, schema *SchemaService, logger *slog.Logger) *DataService {
-	return &DataService{db: db, schema: schema, logger: logger}
+	return &DataService{
+		CountEstimateThreshold: DefaultCountEstimateThreshold,
+
+		db:     db,
+		schema: schema,
+		logger: logger,
+	}
 }
 
 func (s DataService) queryAsJsonArray(sql string, args []any) (json.RawMessage, error) {
@@ -37,6 +57,94 @@ func (s DataService) queryAsJsonArray(sql string, args []any) (json.RawMessage,
 	return result, err
 }
 
+// returns table that can be modified through the data API
+func (s DataService) getWritableTable(tableName string) (*Table, error) {
+	table, err := s.schema.GetTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if table.IsReadOnly() {
+		return nil, fmt.Errorf("%w: %s is a %s", ErrReadOnlyTable, table.Key, table.Kind)
+	}
+
+	return table, nil
+}
+
+// converts filters to WHERE clause and checks raw SQL filters permission,
+// soft-deleted rows are excluded unless requested
+func (s DataService) filtersToSQL(table *Table, filters Filters) (string, []any, error) {
+	where, args, err := s.userFiltersToSQL(table, filters)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return s.notDeletedSQL(table, filters, where), args, nil
+}
+
+// converts only filters passed by user to WHERE clause, so empty filters can be detected
+// before the soft delete condition is added
+func (s DataService) userFiltersToSQL(table *Table, filters Filters) (string, []any, error) {
+	if filters.IsRawSQL() && !s.AllowRawSQLFilters {
+		return "", nil, ErrRawSQLFiltersForbidden
+	}
+
+	return s.resolveTextSearch(table, filters).ToSQL(table)
+}
+
+// adds soft delete condition to WHERE clause, unless deleted rows are requested
+func (s DataService) notDeletedSQL(table *Table, filters Filters, where string) string {
+	if sd := s.softDelete(table); sd != nil && !filters.WithDeleted {
+		return AndWhere(where, sd.NotDeletedSQL())
+	}
+
+	return where
+}
+
+// resolves text search mode from table settings, detected indexes and installed extensions
+func (s DataService) resolveTextSearch(table *Table, filters Filters) Filters {
+	if filters.TextSearch == nil || filters.TextSearch.Mode != TextSearchModeAuto {
+		return filters
+	}
+
+	search := *filters.TextSearch
+	search.Vector = table.SearchVector
+	search.TrigramSchema = s.schema.TrigramSchema()
+
+	if settings, err := s.schema.GetTableSettings(table.Key); err == nil {
+		search.Mode = settings.TextSearchMode
+		search.Config = settings.TextSearchConfig
+	}
+
+	if search.Config == "" && search.Vector != nil {
+		search.Config = search.Vector.Config
+	}
+
+	switch {
+	case search.Mode == TextSearchModeTrigram && search.TrigramSchema == "":
+		search.Mode = TextSearchModeILike
+	case search.Mode != TextSearchModeAuto:
+		// set in table settings
+	default:
+		search.Mode = search.autoMode(table)
+	}
+
+	filters.TextSearch = &search
+	return filters
+}
+
+// returns relevance expression of the text search, empty if results can't be ranked
+func (s DataService) searchRankSQL(table *Table, filters Filters) string {
+	filters = s.resolveTextSearch(table, filters)
+
+	if filters.TextSearch == nil {
+		return ""
+	}
+
+	return filters.TextSearch.RankSQL(table)
+}
+
 // ---------------------- Universal Get Rows -------------------------------
 var getRowsSQL = SqlT(`
 	SELECT {{ .Select }}
@@ -54,20 +162,574 @@ func (s DataService) GetRows(tableName string, params GetRowsParams) (json.RawMe
 		return nil, err
 	}
 
+	if params.WithLabels {
+		table = s.labeledTable(table)
+	}
+
+	return s.getRows(table, params)
+}
+
+func (s DataService) getRows(table *Table, params GetRowsParams) (json.RawMessage, error) {
+	if len(params.Pagination.Cursor) > 0 {
+		page, err := s.getRowsPage(table, params)
+		if err != nil {
+			return nil, err
+		}
+
+		return page.Rows, nil
+	}
+
+	sql, args, err := s.rowsSQL(table, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queryAsJsonArray(sql, args)
+}
+
+// RowsSQL is the rows query of the data API with its args
+type RowsSQL struct {
+	Query string
+	Args  []any
+	// total rows count query of the table view, empty when count isn't requested
+	CountQuery string
+	CountArgs  []any
+}
+
+// GetRowsSQL returns the rows query of GetRows (or the rows page and count queries of the table view)
+// without running them, queries are built by the same builders as the ones that are run.
+func (s DataService) GetRowsSQL(tableName string, params GetRowsParams, tableView bool) (*RowsSQL, error) {
+	table, err := s.schema.GetTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !tableView {
+		if params.WithLabels {
+			table = s.labeledTable(table)
+		}
+
+		if len(params.Pagination.Cursor) > 0 {
+			page, err := s.rowsPageSQL(table, params)
+			if err != nil {
+				return nil, err
+			}
+
+			return &RowsSQL{Query: page.sql, Args: page.args}, nil
+		}
+
+		sql, args, err := s.rowsSQL(table, params)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RowsSQL{Query: sql, Args: args}, nil
+	}
+
+	table, params, err = s.tableViewParams(table, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.rowsPageSQL(table, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RowsSQL{Query: page.sql, Args: page.args}
+
+	// estimated count doesn't run the query, but its plan is the one used for the estimate
+	if params.CountMode != CountModeNone {
+		result.CountQuery, result.CountArgs, err = s.countSQL(table, params.Filters)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (s DataService) rowsSQL(table *Table, params GetRowsParams) (string, []any, error) {
 	selectColumns := params.SelectColumns.ToSQL(table)
-	where, args := params.Filters.ToSQL(table)
-	orderBy := params.Sorting.ToSQL()
+	where, args, err := s.filtersToSQL(table, params.Filters)
+	if err != nil {
+		return "", nil, err
+	}
 
 	sql := getRowsSQL.Exec(map[string]any{
 		"Select":  selectColumns,
-		"From":    table.SafeName(),
+		"From":    table.FromSQL(),
 		"Where":   where,
-		"OrderBy": orderBy,
+		"OrderBy": s.rowsOrderBySQL(table, params),
 		"Limit":   params.Pagination.Limit,
 		"Offset":  params.Pagination.Offset,
 	})
 
-	return s.queryAsJsonArray(sql, args)
+	return sql, args, nil
+}
+
+// search results are ordered by relevance when sorting isn't set
+func (s DataService) rowsOrderBySQL(table *Table, params GetRowsParams) string {
+	orderBy := params.Sorting.ToSQL()
+
+	if rank := s.searchRankSQL(table, params.Filters); params.Sorting.IsEmpty() && rank != "" {
+		orderBy = "ORDER BY " + rank + " DESC"
+
+		if tiebreaker := DefaultTableSorting(table).ToSQL(); tiebreaker != "" {
+			orderBy += ", " + strings.TrimPrefix(tiebreaker, "ORDER BY ")
+		}
+	}
+
+	return orderBy
+}
+
+var getOffsetRowsPageSQL = SqlT(`
+	WITH q AS (
+		SELECT {{.Select}},
+			{{.Version}} AS "__pgpanel_version",
+			row_number() OVER ({{.OrderBy}}) AS "__pgpanel_rn"
+		FROM {{.From}}
+		{{.Where}}
+		{{.OrderBy}}
+		LIMIT {{.Limit}}
+		OFFSET {{.Offset}}
+	)
+	SELECT
+		COALESCE((
+			SELECT json_agg(row_to_json(r) ORDER BY q."__pgpanel_rn")
+			FROM q, LATERAL (SELECT {{.RowColumns}}) r
+		), '[]'::json),
+		COALESCE((
+			SELECT json_agg("__pgpanel_version" ORDER BY "__pgpanel_rn")
+			FROM q
+		), '[]'::json)
+`)
+
+// offsetRowsPageSQL is used when keyset pagination isn't possible,
+// it still returns row versions, so updates can be checked with If-Match.
+// Views can't be updated, so they get the plain rows query without versions.
+func (s DataService) offsetRowsPageSQL(table *Table, params GetRowsParams) (string, []any, error) {
+	if table.IsReadOnly() {
+		return s.rowsSQL(table, params)
+	}
+
+	where, args, err := s.filtersToSQL(table, params.Filters)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var rowColumns []string
+	for _, col := range params.SelectColumns.ToColumns(table) {
+		rowColumns = append(rowColumns, "q."+col.SafeName())
+	}
+
+	sql := getOffsetRowsPageSQL.Exec(map[string]any{
+		"Select":     params.SelectColumns.ToSQL(table),
+		"From":       table.FromSQL(),
+		"Where":      where,
+		"OrderBy":    s.rowsOrderBySQL(table, params),
+		"Limit":      params.Pagination.Limit,
+		"Offset":     params.Pagination.Offset,
+		"RowColumns": strings.Join(rowColumns, ", "),
+		"Version":    s.rowVersionSQL(table),
+	})
+
+	return sql, args, nil
+}
+
+func (s DataService) getOffsetRowsPage(table *Table, sql string, args []any) (*RowsPage, error) {
+	if table.IsReadOnly() {
+		rows, err := s.queryAsJsonArray(sql, args)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RowsPage{Rows: rows}, nil
+	}
+
+	var page RowsPage
+
+	if err := s.db.QueryRow(context.TODO(), sql, args...).Scan(&page.Rows, &page.Versions); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// ---------------------- Rows Page (keyset pagination) -------------------------------
+var getRowsPageSQL = SqlT(`
+	WITH q AS (
+		SELECT {{.Select}},
+			json_build_array({{.Keys}}) AS "__pgpanel_key",
+			{{.Version}} AS "__pgpanel_version",
+			row_number() OVER ({{.OrderBy}}) AS "__pgpanel_rn"
+		FROM {{.From}}
+		{{.Where}}
+		{{.OrderBy}}
+		LIMIT {{.Limit}} + 1
+		OFFSET {{.Offset}}
+	),
+	page AS (
+		SELECT * FROM q ORDER BY "__pgpanel_rn" LIMIT {{.Limit}}
+	)
+	SELECT
+		COALESCE((
+			SELECT json_agg(row_to_json(r) ORDER BY page."__pgpanel_rn" {{.RowsOrder}})
+			FROM page, LATERAL (SELECT {{.RowColumns}}) r
+		), '[]'::json),
+		(SELECT "__pgpanel_key" FROM page ORDER BY "__pgpanel_rn" ASC LIMIT 1),
+		(SELECT "__pgpanel_key" FROM page ORDER BY "__pgpanel_rn" DESC LIMIT 1),
+		(SELECT COUNT(*) FROM q) > {{.Limit}},
+		COALESCE((
+			SELECT json_agg("__pgpanel_version" ORDER BY "__pgpanel_rn" {{.RowsOrder}})
+			FROM page
+		), '[]'::json)
+`)
+
+type RowsPage struct {
+	Rows       json.RawMessage
+	NextCursor string
+	PrevCursor string
+	// row version tokens in the same order as rows
+	Versions []string
+}
+
+// GetRowsPage works like GetRows but also returns cursors to the next and previous pages.
+// When Pagination.Cursor is set it uses keyset pagination instead of OFFSET.
+func (s DataService) GetRowsPage(tableName string, params GetRowsParams) (*RowsPage, error) {
+	table, err := s.schema.GetTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if params.WithLabels {
+		table = s.labeledTable(table)
+	}
+
+	return s.getRowsPage(table, params)
+}
+
+// rows page query with the state needed to build cursors from its result
+type rowsPageQuery struct {
+	sql  string
+	args []any
+	// keyset pagination isn't possible, query is built by offsetRowsPageSQL
+	isOffset bool
+
+	sorting  Sorting
+	cursor   *Cursor
+	offset   int
+	backward bool
+}
+
+func (s DataService) rowsPageSQL(table *Table, params GetRowsParams) (*rowsPageQuery, error) {
+	offsetPage := func() (*rowsPageQuery, error) {
+		sql, args, err := s.offsetRowsPageSQL(table, params)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rowsPageQuery{sql: sql, args: args, isOffset: true}, nil
+	}
+
+	// relevance can't be used as a keyset, so ranked search results use offset pagination
+	if params.Sorting.IsEmpty() && len(params.Pagination.Cursor) == 0 && s.searchRankSQL(table, params.Filters) != "" {
+		return offsetPage()
+	}
+
+	sorting, hasPrimaryKey, err := KeysetSorting(table, params.Sorting)
+	if err != nil {
+		return nil, err
+	}
+
+	// rows can't be identified without primary key, so fallback to regular offset pagination
+	if !hasPrimaryKey {
+		if len(params.Pagination.Cursor) > 0 {
+			return nil, fmt.Errorf("%w: table doesn't have primary key", ErrInvalidCursor)
+		}
+
+		return offsetPage()
+	}
+
+	where, args, err := s.filtersToSQL(table, params.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor *Cursor
+	offset := params.Pagination.Offset
+	querySorting := sorting
+
+	if len(params.Pagination.Cursor) > 0 {
+		cursor, err = DecodeCursor(params.Pagination.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		if cursor.Sorting != sorting.String() {
+			return nil, fmt.Errorf("%w: sorting has been changed", ErrInvalidCursor)
+		}
+
+		// fetch previous page in reversed order and then flip it back
+		if cursor.Backward {
+			querySorting = sorting.Reverse()
+		}
+
+		keyset, keysetArgs, err := querySorting.KeysetSQL(table, cursor, len(args))
+		if err != nil {
+			return nil, err
+		}
+
+		where = AndWhere(where, keyset)
+		args = append(args, keysetArgs...)
+		offset = 0
+	}
+
+	backward := cursor != nil && cursor.Backward
+
+	keys := make([]string, len(sorting.Fields))
+	for i, f := range sorting.Fields {
+		// sorting fields are validated by KeysetSorting
+		col, _ := table.GetColumn(f.Name)
+		keys[i] = col.SafeName()
+	}
+
+	var rowColumns []string
+	for _, col := range params.SelectColumns.ToColumns(table) {
+		rowColumns = append(rowColumns, "page."+col.SafeName())
+	}
+
+	rowsOrder := "ASC"
+	if backward {
+		rowsOrder = "DESC"
+	}
+
+	sql := getRowsPageSQL.Exec(map[string]any{
+		"Select":     params.SelectColumns.ToSQL(table),
+		"Keys":       strings.Join(keys, ", "),
+		"From":       table.FromSQL(),
+		"Where":      where,
+		"OrderBy":    querySorting.ToSQL(),
+		"Limit":      params.Pagination.Limit,
+		"Offset":     offset,
+		"RowsOrder":  rowsOrder,
+		"RowColumns": strings.Join(rowColumns, ", "),
+		"Version":    s.rowVersionSQL(table),
+	})
+
+	return &rowsPageQuery{
+		sql:      sql,
+		args:     args,
+		sorting:  sorting,
+		cursor:   cursor,
+		offset:   offset,
+		backward: backward,
+	}, nil
+}
+
+func (s DataService) getRowsPage(table *Table, params GetRowsParams) (*RowsPage, error) {
+	q, err := s.rowsPageSQL(table, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.isOffset {
+		return s.getOffsetRowsPage(table, q.sql, q.args)
+	}
+
+	sorting, cursor, offset, backward := q.sorting, q.cursor, q.offset, q.backward
+
+	var page RowsPage
+	var firstKey, lastKey []byte
+	var hasMore bool
+
+	err = s.db.QueryRow(context.TODO(), q.sql, q.args...).Scan(&page.Rows, &firstKey, &lastKey, &hasMore, &page.Versions)
+	if err != nil {
+		return nil, err
+	}
+
+	// empty page, nothing to point to
+	if firstKey == nil || lastKey == nil {
+		return &page, nil
+	}
+
+	// keys are in the query order, so swap them for backward page
+	if backward {
+		firstKey, lastKey = lastKey, firstKey
+	}
+
+	makeCursor := func(key []byte, backward bool) (string, error) {
+		decoder := json.NewDecoder(strings.NewReader(string(key)))
+		decoder.UseNumber()
+
+		c := Cursor{Backward: backward, Sorting: sorting.String()}
+		if err := decoder.Decode(&c.Values); err != nil {
+			return "", err
+		}
+
+		return c.Encode(), nil
+	}
+
+	hasNext := hasMore || backward
+	hasPrev := (hasMore && backward) || (!backward && (cursor != nil || offset > 0))
+
+	if hasNext {
+		if page.NextCursor, err = makeCursor(lastKey, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if hasPrev {
+		if page.PrevCursor, err = makeCursor(firstKey, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return &page, nil
+}
+
+// ---------------------- Rows Count -------------------------------
+
+type RowsCount struct {
+	Count     int64 `json:"count"`
+	Estimated bool  `json:"estimated"`
+}
+
+var countRowsSQL = SqlT(`
+	SELECT COUNT(*)
+	FROM {{.From}}
+	{{.Where}}
+`)
+
+var explainCountRowsSQL = SqlT(`
+	EXPLAIN (FORMAT JSON)
+	SELECT 1
+	FROM {{.From}}
+	{{.Where}}
+`)
+
+// CountRows returns total rows matched by filters.
+// Returns nil for CountModeNone.
+func (s DataService) CountRows(tableName string, filters Filters, mode CountMode) (*RowsCount, error) {
+	table, err := s.schema.GetTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.countRows(table, filters, mode)
+}
+
+func (s DataService) countRows(table *Table, filters Filters, mode CountMode) (*RowsCount, error) {
+	if mode == CountModeNone {
+		return nil, nil
+	}
+
+	where, args, err := s.filtersToSQL(table, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.TODO()
+
+	if mode != CountModeExact && mode != CountModeEstimated {
+		tableRows, err := s.estimateTableRows(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		// small tables are cheap to count exactly
+		mode = CountModeExact
+		if tableRows > s.CountEstimateThreshold {
+			mode = CountModeEstimated
+		}
+	}
+
+	params := countRowsParams(table, where)
+
+	if mode == CountModeExact {
+		var count int64
+		err := s.db.QueryRow(ctx, countRowsSQL.Exec(params), args...).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RowsCount{Count: count}, nil
+	}
+
+	if len(where) == 0 {
+		count, err := s.estimateTableRows(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		return &RowsCount{Count: count, Estimated: true}, nil
+	}
+
+	count, err := s.explainRowsEstimate(ctx, explainCountRowsSQL.Exec(params), args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RowsCount{Count: count, Estimated: true}, nil
+}
+
+func countRowsParams(table *Table, where string) map[string]any {
+	return map[string]any{
+		"From":  table.FromSQL(),
+		"Where": where,
+	}
+}
+
+// returns the exact count query of countRows
+func (s DataService) countSQL(table *Table, filters Filters) (string, []any, error) {
+	where, args, err := s.filtersToSQL(table, filters)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return countRowsSQL.Exec(countRowsParams(table, where)), args, nil
+}
+
+// uses planner statistics, falls back to EXPLAIN if table was never analyzed
+func (s DataService) estimateTableRows(ctx context.Context, table *Table) (int64, error) {
+	var reltuples int64
+
+	err := s.db.QueryRow(ctx, `SELECT reltuples::bigint FROM pg_class WHERE oid = $1::regclass`, table.SafeName()).Scan(&reltuples)
+	if err != nil {
+		return 0, err
+	}
+
+	if reltuples >= 0 {
+		return reltuples, nil
+	}
+
+	return s.explainRowsEstimate(ctx, explainCountRowsSQL.Exec(map[string]any{"From": table.SafeName()}), nil)
+}
+
+func (s DataService) explainRowsEstimate(ctx context.Context, sql string, args []any) (int64, error) {
+	var plans []struct {
+		Plan struct {
+			PlanRows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+
+	var raw json.RawMessage
+	if err := s.db.QueryRow(ctx, sql, args...).Scan(&raw); err != nil {
+		return 0, err
+	}
+
+	if err := json.Unmarshal(raw, &plans); err != nil {
+		return 0, err
+	}
+
+	if len(plans) == 0 {
+		return 0, errors.New("empty explain plan")
+	}
+
+	return int64(plans[0].Plan.PlanRows), nil
 }
 
 // ---------------------- Universal Update Rows -------------------------------
@@ -90,41 +752,137 @@ func (rr RawRow) ToUpdateSQL(table *Table, paramsOffset int) (string, []any) {
 		i += 1
 	}
 
-	sql := strings.Join(updates, ", ")
+	sql := strings.Join(updates, ", ")
+
+	return sql, args
+}
+
+var updateRowsSQL = SqlT(`
+	UPDATE {{.TableName}}
+	SET {{.Updates}}
+	{{.Where}}
+	RETURNING *
+`)
+
+func (s DataService) UpdateRows(tableName string, filters Filters, row RawRow) (json.RawMessage, error) {
+	table, err := s.getWritableTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	where, whereArgs, err := s.filtersToSQL(table, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateRows(table, where, whereArgs, row)
+}
+
+func (s DataService) updateRows(table *Table, where string, whereArgs []any, row RawRow) (json.RawMessage, error) {
+	updates, updatesArgs := row.ToUpdateSQL(table, len(whereArgs))
+
+	if len(updates) == 0 {
+		return nil, errors.New("can't update rows with zero valid columns")
+	}
+
+	args := append(whereArgs, updatesArgs...)
+
+	sql := updateRowsSQL.Exec(map[string]any{
+		"TableName": table.SafeName(),
+		"Updates":   updates,
+		"Where":     where,
+	})
+
+	return s.queryAudited(table, auditedStatement{
+		operation: AuditUpdate,
+		sql:       sql,
+		args:      args,
+		where:     where,
+		whereArgs: whereArgs,
+	})
+}
+
+// ---------------------- Row Version (optimistic concurrency) -------------------------------
+
+var ErrStaleRow = errors.New("row has been changed since it was read")
+
+// StaleRowError is returned when row version doesn't match, Current contains the actual row
+type StaleRowError struct {
+	Current json.RawMessage
+}
+
+func (e *StaleRowError) Error() string {
+	return ErrStaleRow.Error()
+}
+
+func (e *StaleRowError) Unwrap() error {
+	return ErrStaleRow
+}
+
+// Row version expression: configured version column (e.g. updated_at) or xmin system column.
+// Version column has to be changed on every update (e.g. by trigger) to detect conflicts.
+func (s DataService) rowVersionSQL(table *Table) string {
+	settings, err := s.schema.GetTableSettings(table.Key)
 
-	return sql, args
+	if err == nil && len(settings.VersionColumn) > 0 {
+		if col, ok := table.GetColumn(settings.VersionColumn); ok {
+			return col.SafeName() + "::text"
+		}
+	}
+
+	return "xmin::text"
 }
 
-var updateRowsSQL = SqlT(`
-	UPDATE {{.TableName}}
-	SET {{.Updates}}
-	{{.Where}}
-	RETURNING *
-`)
+// UpdateRowsIfMatch updates rows only if their version still matches the one that was read
+func (s DataService) UpdateRowsIfMatch(tableName string, filters Filters, row RawRow, version string) (json.RawMessage, error) {
+	table, err := s.getWritableTable(tableName)
 
-func (s DataService) UpdateRows(tableName string, filters Filters, row RawRow) (json.RawMessage, error) {
-	table, err := s.schema.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
 
+	where, whereArgs, err := s.userFiltersToSQL(table, filters)
 	if err != nil {
 		return nil, err
 	}
 
-	where, whereArgs := filters.ToSQL(table)
-	updates, updatesArgs := row.ToUpdateSQL(table, len(whereArgs))
+	if len(where) == 0 {
+		return nil, errors.New("can't update rows by version with empty filters")
+	}
 
-	if len(updates) == 0 {
-		return nil, errors.New("can't update rows with zero valid columns")
+	where = s.notDeletedSQL(table, filters, where)
+
+	versionWhere := AndWhere(where, fmt.Sprintf("%s = $%d", s.rowVersionSQL(table), len(whereArgs)+1))
+	versionArgs := append(slices.Clone(whereArgs), version)
+
+	rows, err := s.updateRows(table, versionWhere, versionArgs, row)
+	if err != nil {
+		return nil, err
 	}
 
-	args := append(whereArgs, updatesArgs...)
+	if !isEmptyJsonArray(rows) {
+		return rows, nil
+	}
 
-	sql := updateRowsSQL.Exec(map[string]any{
-		"TableName": table.SafeName(),
-		"Updates":   updates,
-		"Where":     where,
+	// nothing updated, so the row either doesn't exist or has a different version
+	current, err := s.GetRows(tableName, GetRowsParams{
+		Filters:    filters,
+		Pagination: Pagination{Limit: 1},
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return s.queryAsJsonArray(sql, args)
+	if isEmptyJsonArray(current) {
+		return rows, nil
+	}
+
+	return nil, &StaleRowError{Current: current}
+}
+
+func isEmptyJsonArray(data json.RawMessage) bool {
+	return string(bytes.TrimSpace(data)) == "[]"
 }
 
 // ---------------------- Universal Insert Row -------------------------------
@@ -162,7 +920,7 @@ var insertRowSQL = SqlT(`
 `)
 
 func (s DataService) InsertRow(tableName string, row RawRow) (json.RawMessage, error) {
-	table, err := s.schema.GetTable(tableName)
+	table, err := s.getWritableTable(tableName)
 
 	if err != nil {
 		return nil, err
@@ -180,7 +938,69 @@ func (s DataService) InsertRow(tableName string, row RawRow) (json.RawMessage, e
 		"Values":    insertValues,
 	})
 
-	return s.queryAsJsonArray(sql, args)
+	return s.queryAudited(table, auditedStatement{operation: AuditInsert, sql: sql, args: args})
+}
+
+// ---------------------- Universal Upsert Row -------------------------------
+var upsertRowSQL = SqlT(`
+	INSERT INTO {{.TableName}} {{.Columns}}
+	VALUES {{.Values}}
+	{{.OnConflict}}
+	RETURNING *
+`)
+
+// UpsertRow inserts row or updates (ignores) the existing one on conflict
+func (s DataService) UpsertRow(tableName string, row RawRow, options UpsertOptions) (json.RawMessage, error) {
+	table, err := s.getWritableTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	insertColumns, insertValues, args := row.ToInsertSQL(table, 0)
+
+	if len(insertColumns) == 0 {
+		return nil, errors.New("can't insert row with zero valid columns")
+	}
+
+	var rowColumns []string
+	for name := range row {
+		rowColumns = append(rowColumns, name)
+	}
+
+	onConflict, err := options.ToSQL(table, rowColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := upsertRowSQL.Exec(map[string]any{
+		"TableName":  table.SafeName(),
+		"Columns":    insertColumns,
+		"Values":     insertValues,
+		"OnConflict": onConflict,
+	})
+
+	st := auditedStatement{operation: AuditUpsert, sql: sql, args: args, keyColumns: options.OnConflict}
+
+	// conflicting row is found by conflict target values to get its before image
+	var conds []string
+	for _, col := range table.GetColumns(options.OnConflict) {
+		value, ok := row[col.Name]
+		if !ok {
+			conds = nil
+			break
+		}
+
+		st.args = append(st.args, value)
+		conds = append(conds, fmt.Sprintf("%s = $%d", col.SafeName(), len(st.args)))
+	}
+
+	if len(conds) > 0 {
+		st.where = "WHERE " + strings.Join(conds, " AND ")
+		st.whereArgs = st.args[len(args):]
+	}
+
+	return s.queryAudited(table, st)
 }
 
 // ---------------------- Universal Delete Rows -------------------------------
@@ -190,34 +1010,160 @@ var deleteRowsSQL = SqlT(`
 	RETURNING *
 `)
 
+// returns DELETE or soft delete UPDATE statement with its args
+func (s DataService) deleteStatement(table *Table, where string, whereArgs []any) (string, []any) {
+	if sd := s.softDelete(table); sd != nil {
+		value, args := sd.ValueSQL(whereArgs)
+
+		return softDeleteRowsSQL.Exec(map[string]any{
+			"TableName": table.SafeName(),
+			"Column":    sd.Column.SafeName(),
+			"Value":     value,
+			"Where":     where,
+		}), args
+	}
+
+	return deleteRowsSQL.Exec(map[string]any{
+		"TableName": table.SafeName(),
+		"Where":     where,
+	}), whereArgs
+}
+
 func (s DataService) DeleteRows(tableName string, filters Filters) (json.RawMessage, error) {
-	table, err := s.schema.GetTable(tableName)
+	table, err := s.getWritableTable(tableName)
 
 	if err != nil {
 		return nil, err
 	}
 
-	where, args := filters.ToSQL(table)
+	where, whereArgs, err := s.userFiltersToSQL(table, filters)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(where) == 0 {
 		return nil, errors.New("can't delete rows with empty filters")
 	}
 
-	sql := deleteRowsSQL.Exec(map[string]any{
-		"TableName": table.SafeName(),
-		"Where":     where,
+	where = s.notDeletedSQL(table, filters, where)
+	sql, args := s.deleteStatement(table, where, whereArgs)
+
+	return s.queryAudited(table, auditedStatement{
+		operation: AuditDelete,
+		sql:       sql,
+		args:      args,
+		where:     where,
+		whereArgs: whereArgs,
+		deleted:   s.softDelete(table) == nil,
 	})
+}
 
-	return s.queryAsJsonArray(sql, args)
+// ---------------------- Refresh Materialized View -------------------------------
+
+type MaterializedViewRefresh struct {
+	Table        string    `json:"table"`
+	Concurrently bool      `json:"concurrently"`
+	RefreshedAt  time.Time `json:"refreshedAt"`
+	DurationMs   float64   `json:"durationMs"`
+}
+
+// RefreshMaterializedView re-runs view query. CONCURRENTLY doesn't block reads,
+// but requires a unique index on the view.
+func (s DataService) RefreshMaterializedView(tableName string, concurrently bool) (*MaterializedViewRefresh, error) {
+	table, err := s.schema.GetTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if table.Kind != TableKindMaterializedView {
+		return nil, fmt.Errorf("%w: %s", ErrNotMaterializedView, table.Key)
+	}
+
+	sql := "REFRESH MATERIALIZED VIEW " + table.SafeName()
+	if concurrently {
+		sql = "REFRESH MATERIALIZED VIEW CONCURRENTLY " + table.SafeName()
+	}
+
+	ctx := context.Background()
+	started := time.Now()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditSummary(ctx, tx, table, AuditRefresh, map[string]any{"concurrently": concurrently}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &MaterializedViewRefresh{
+		Table:        table.Key,
+		Concurrently: concurrently,
+		RefreshedAt:  time.Now(),
+		DurationMs:   float64(time.Since(started).Microseconds()) / 1000,
+	}, nil
 }
 
 // ---------------------- Relations -------------------------------
 
+// RowKey identifies a row by key columns values, e.g. {"tenant_id": 1, "id": 5}
+type RowKey map[string]any
+
+// RowKeyFromValue accepts JSON object (or map) with key columns
+// or a plain value for single column keys
+func RowKeyFromValue(v any, keyColumns []string) (RowKey, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		return RowKey(val), nil
+	case RowKey:
+		return val, nil
+	case string:
+		if strings.HasPrefix(strings.TrimSpace(val), "{") {
+			var key RowKey
+			if err := json.Unmarshal([]byte(val), &key); err != nil {
+				return nil, fmt.Errorf("invalid row key: %w", err)
+			}
+			return key, nil
+		}
+	}
+
+	if len(keyColumns) != 1 {
+		return nil, fmt.Errorf("composite key (%s) has to be passed as JSON object", strings.Join(keyColumns, ", "))
+	}
+
+	return RowKey{keyColumns[0]: v}, nil
+}
+
+// Values returns key values in the columns order
+func (k RowKey) Values(columns []string) ([]any, error) {
+	values := make([]any, len(columns))
+
+	for i, name := range columns {
+		v, ok := k[name]
+		if !ok || v == nil {
+			return nil, fmt.Errorf("row key is missing %q column", name)
+		}
+		values[i] = v
+	}
+
+	return values, nil
+}
+
 var getRelatedRows = SqlT(`
 	SELECT {{.Select}}
 	FROM {{.RelationTable}}
-	JOIN {{.JoinTable}} ON {{.JoinTable}}.{{.RelationJoinCol}} = {{.RelationTable}}.{{.RelationTableCol}}
-	WHERE {{.JoinTable}}.{{.MainJoinCol}} = $1
+	JOIN {{.JoinTable}} ON {{.JoinOn}}
+	WHERE {{.Where}}
 `)
 
 type relationData struct {
@@ -225,9 +1171,9 @@ type relationData struct {
 	relationTable *Table
 	joinTable     *Table
 
-	mainJoinCol      *Column
-	relationJoinCol  *Column
-	relationTableCol *Column
+	// join table foreign keys to main and relation tables
+	mainJoinKey     ForeignKey
+	relationJoinKey ForeignKey
 }
 
 func (s DataService) getRelationData(relation *RelationsConfig) (*relationData, error) {
@@ -244,36 +1190,39 @@ func (s DataService) getRelationData(relation *RelationsConfig) (*relationData,
 		return nil, errors.New("unknown joinTable")
 	}
 
-	var mainJoinCol *Column
-	var relationJoinCol *Column
-	var ok bool
+	var mainJoinKey ForeignKey
+	var relationJoinKey ForeignKey
 
 	// handle edge case where MainTable and RelationTable are the same
-	if relation.MainTable == relation.RelationTable {
-		cols := joinTable.GetForeignKeyColumnsByTable(relation.MainTable)
+	if mainTable == relationTable {
+		fks := joinTable.GetForeignKeysByTable(mainTable.Key)
 
-		if len(cols) < 2 {
-			return nil, errors.New("can't get mainJoinCol, relationJoinCol")
+		if len(fks) < 2 {
+			return nil, errors.New("can't get mainJoinKey, relationJoinKey")
 		}
 
-		mainJoinCol = &cols[0]
-		relationJoinCol = &cols[1]
+		mainJoinKey = fks[0]
+		relationJoinKey = fks[1]
 
 	} else {
-		mainJoinCol, ok = joinTable.GetForeignKeyColumnByTable(mainTable.Name)
-		if !ok {
-			return nil, errors.New("can't get mainJoinCol")
+		mainFks := joinTable.GetForeignKeysByTable(mainTable.Key)
+		if len(mainFks) == 0 {
+			return nil, errors.New("can't get mainJoinKey")
 		}
 
-		relationJoinCol, ok = joinTable.GetForeignKeyColumnByTable(relationTable.Name)
-		if !ok {
-			return nil, errors.New("can't get relationJoinCol")
+		relationFks := joinTable.GetForeignKeysByTable(relationTable.Key)
+		if len(relationFks) == 0 {
+			return nil, errors.New("can't get relationJoinKey")
 		}
+
+		mainJoinKey = mainFks[0]
+		relationJoinKey = relationFks[0]
 	}
 
-	relationTableCol, ok := relationTable.GetColumn(relationJoinCol.ForeignKey.ColumnName)
-	if !ok {
-		return nil, errors.New("can't get relationTableCol")
+	for _, name := range relationJoinKey.ReferencedColumns() {
+		if _, ok := relationTable.GetColumn(name); !ok {
+			return nil, errors.New("can't get relationTableCol")
+		}
 	}
 
 	return &relationData{
@@ -281,13 +1230,33 @@ func (s DataService) getRelationData(relation *RelationsConfig) (*relationData,
 		relationTable: relationTable,
 		joinTable:     joinTable,
 
-		mainJoinCol:      mainJoinCol,
-		relationJoinCol:  relationJoinCol,
-		relationTableCol: relationTableCol,
+		mainJoinKey:     mainJoinKey,
+		relationJoinKey: relationJoinKey,
 	}, nil
 }
 
-// Return all related rows for specific main table row ID
+// builds "join"."col1" = $1 AND "join"."col2" = $2 for key values
+func (rd *relationData) joinKeyWhere(fk ForeignKey, key RowKey, paramsOffset int) (string, []any, error) {
+	values, err := key.Values(fk.ReferencedColumns())
+	if err != nil {
+		return "", nil, err
+	}
+
+	conds := make([]string, len(fk.Columns))
+	args := make([]any, len(fk.Columns))
+
+	for i, col := range fk.Columns {
+		conds[i] = fmt.Sprintf("%s.%s = $%d", rd.joinTable.SafeName(), col.SafeName(), i+1+paramsOffset)
+
+		if args[i], err = filterArgValue(&col, values[i]); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return strings.Join(conds, " AND "), args, nil
+}
+
+// Return all related rows for specific main table row ID (or composite key)
 func (s DataService) GetRelatedRows(relation *RelationsConfig, mainTableRowId any) (json.RawMessage, error) {
 	rd, err := s.getRelationData(relation)
 
@@ -295,32 +1264,51 @@ func (s DataService) GetRelatedRows(relation *RelationsConfig, mainTableRowId an
 		return nil, err
 	}
 
-	selectColumns := strings.Join(rd.relationTable.SafeColumnNames(), ",")
+	mainKey, err := RowKeyFromValue(mainTableRowId, rd.mainJoinKey.ReferencedColumns())
+	if err != nil {
+		return nil, err
+	}
+
+	where, args, err := rd.joinKeyWhere(rd.mainJoinKey, mainKey, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	selectColumns := make([]string, len(rd.relationTable.Columns))
+	for i, col := range rd.relationTable.Columns {
+		selectColumns[i] = rd.relationTable.SafeName() + "." + col.SafeName()
+	}
+
+	refColumns := rd.relationJoinKey.ReferencedColumns()
+	joinOn := make([]string, len(rd.relationJoinKey.Columns))
+	for i, col := range rd.relationJoinKey.Columns {
+		joinOn[i] = fmt.Sprintf(`%s.%s = %s."%s"`, rd.joinTable.SafeName(), col.SafeName(), rd.relationTable.SafeName(), refColumns[i])
+	}
+
 	params := map[string]string{
-		"Select":        selectColumns,
+		"Select":        strings.Join(selectColumns, ","),
 		"RelationTable": rd.relationTable.SafeName(),
 		"JoinTable":     rd.joinTable.SafeName(),
-
-		"MainJoinCol":      rd.mainJoinCol.SafeName(),
-		"RelationJoinCol":  rd.relationJoinCol.SafeName(),
-		"RelationTableCol": rd.relationTableCol.SafeName(),
+		"JoinOn":        strings.Join(joinOn, " AND "),
+		"Where":         where,
 	}
 
 	sql := getRelatedRows.Exec(&params)
-	args := []any{mainTableRowId}
 
 	return s.queryAsJsonArray(sql, args)
 }
 
 var deleteRelatedRow = SqlT(`
     DELETE FROM {{.JoinTable}}
-    WHERE {{.MainJoinCol}} = $1 AND {{.RelationJoinCol}} = $2
+    WHERE {{.Where}}
+    RETURNING *
 `)
 
 var insertRelatedRow = SqlT(`
-    INSERT INTO {{.JoinTable}} ({{.MainJoinCol}}, {{.RelationJoinCol}})
-    VALUES ($1, $2)
-    ON CONFLICT ({{.MainJoinCol}}, {{.RelationJoinCol}}) DO NOTHING
+    INSERT INTO {{.JoinTable}} ({{.Columns}})
+    VALUES ({{.Values}})
+    ON CONFLICT DO NOTHING
+    RETURNING *
 `)
 
 type UpdateRelatedRowsActions struct {
@@ -328,16 +1316,111 @@ type UpdateRelatedRowsActions struct {
 	DeleteIds []any `json:"deleteIds"`
 }
 
+// join table row that links main and relation rows
+type relationLink struct {
+	where   string
+	columns string
+	values  string
+	args    []any
+	// args of the insert, shared columns are inserted once
+	insertArgs []any
+}
+
+func (rd *relationData) link(mainKey RowKey, relationKey RowKey) (*relationLink, error) {
+	mainWhere, mainArgs, err := rd.joinKeyWhere(rd.mainJoinKey, mainKey, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	relationWhere, relationArgs, err := rd.joinKeyWhere(rd.relationJoinKey, relationKey, len(mainArgs))
+	if err != nil {
+		return nil, err
+	}
+
+	args := append(mainArgs, relationArgs...)
+
+	var columns []string
+	var values []string
+	var insertArgs []any
+	// columns shared by both keys (e.g. tenant_id) are inserted once
+	inserted := make(map[string]any)
+
+	for i, col := range append(slices.Clone(rd.mainJoinKey.Columns), rd.relationJoinKey.Columns...) {
+		if v, ok := inserted[col.Name]; ok {
+			if fmt.Sprint(v) != fmt.Sprint(args[i]) {
+				return nil, fmt.Errorf("main and relation keys have different values of %q", col.Name)
+			}
+			continue
+		}
+
+		inserted[col.Name] = args[i]
+		insertArgs = append(insertArgs, args[i])
+		columns = append(columns, col.SafeName())
+		values = append(values, fmt.Sprintf("$%d", len(insertArgs)))
+	}
+
+	return &relationLink{
+		where:      mainWhere + " AND " + relationWhere,
+		columns:    strings.Join(columns, ", "),
+		values:     strings.Join(values, ", "),
+		args:       args,
+		insertArgs: insertArgs,
+	}, nil
+}
+
 func (s DataService) UpdateRelatedRows(relation *RelationsConfig, mainTableRowId any, actions *UpdateRelatedRowsActions) error {
 	rd, err := s.getRelationData(relation)
 	if err != nil {
 		return err
 	}
 
-	params := map[string]string{
-		"JoinTable":       rd.joinTable.SafeName(),
-		"MainJoinCol":     rd.mainJoinCol.SafeName(),
-		"RelationJoinCol": rd.relationJoinCol.SafeName(),
+	if rd.joinTable.IsReadOnly() {
+		return fmt.Errorf("%w: %s is a %s", ErrReadOnlyTable, rd.joinTable.Key, rd.joinTable.Kind)
+	}
+
+	mainKey, err := RowKeyFromValue(mainTableRowId, rd.mainJoinKey.ReferencedColumns())
+	if err != nil {
+		return err
+	}
+
+	relationKeyColumns := rd.relationJoinKey.ReferencedColumns()
+
+	// collect links for main and (if bidirectional) reverse directions
+	links := func(ids []any) ([]*relationLink, error) {
+		var res []*relationLink
+
+		for _, id := range ids {
+			relationKey, err := RowKeyFromValue(id, relationKeyColumns)
+			if err != nil {
+				return nil, err
+			}
+
+			l, err := rd.link(mainKey, relationKey)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, l)
+
+			if relation.Bidirectional {
+				reverse, err := rd.link(relationKey, mainKey)
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, reverse)
+			}
+		}
+
+		return res, nil
+	}
+
+	deleteLinks, err := links(actions.DeleteIds)
+	if err != nil {
+		return err
+	}
+
+	addLinks, err := links(actions.AddIds)
+	if err != nil {
+		return err
 	}
 
 	ctx := context.Background()
@@ -350,34 +1433,38 @@ func (s DataService) UpdateRelatedRows(relation *RelationsConfig, mainTableRowId
 	defer tx.Rollback(ctx)
 
 	// Process deletions
-	deleteSQL := deleteRelatedRow.Exec(&params)
-	for _, deleteId := range actions.DeleteIds {
-		// Delete main direction
-		if _, err := tx.Exec(ctx, deleteSQL, mainTableRowId, deleteId); err != nil {
-			return err
+	for _, l := range deleteLinks {
+		sql := deleteRelatedRow.Exec(map[string]string{
+			"JoinTable": rd.joinTable.SafeName(),
+			"Where":     l.where,
+		})
+
+		st := auditedStatement{
+			operation: AuditDelete,
+			sql:       sql,
+			args:      l.args,
+			where:     "WHERE " + l.where,
+			whereArgs: l.args,
+			deleted:   true,
 		}
 
-		// If bidirectional, delete reverse direction
-		if relation.Bidirectional {
-			if _, err := tx.Exec(ctx, deleteSQL, deleteId, mainTableRowId); err != nil {
-				return err
-			}
+		if _, err := s.queryAuditedTx(ctx, tx, rd.joinTable, st); err != nil {
+			return err
 		}
 	}
 
 	// Process additions
-	insertSQL := insertRelatedRow.Exec(&params)
-	for _, addId := range actions.AddIds {
-		// Insert main direction
-		if _, err := tx.Exec(ctx, insertSQL, mainTableRowId, addId); err != nil {
-			return err
-		}
+	for _, l := range addLinks {
+		sql := insertRelatedRow.Exec(map[string]string{
+			"JoinTable": rd.joinTable.SafeName(),
+			"Columns":   l.columns,
+			"Values":    l.values,
+		})
 
-		// If bidirectional, insert reverse direction
-		if relation.Bidirectional {
-			if _, err := tx.Exec(ctx, insertSQL, addId, mainTableRowId); err != nil {
-				return err
-			}
+		st := auditedStatement{operation: AuditInsert, sql: sql, args: l.insertArgs}
+
+		if _, err := s.queryAuditedTx(ctx, tx, rd.joinTable, st); err != nil {
+			return err
 		}
 	}
 
@@ -392,8 +1479,12 @@ func (s DataService) UpdateRelatedRows(relation *RelationsConfig, mainTableRowId
 // ---------------------- Composite View Methods -------------------------------
 
 type TableView struct {
-	Rows    json.RawMessage `json:"rows"`
-	Columns []Column        `json:"columns"`
+	Rows       json.RawMessage `json:"rows"`
+	Columns    []Column        `json:"columns"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+	PrevCursor string          `json:"prevCursor,omitempty"`
+	Total      *RowsCount      `json:"total,omitempty"`
+	Versions   []string        `json:"versions,omitempty"`
 }
 
 func (s DataService) GetTableView(tableName string, params GetRowsParams) (*TableView, error) {
@@ -403,15 +1494,53 @@ func (s DataService) GetTableView(tableName string, params GetRowsParams) (*Tabl
 		return nil, err
 	}
 
-	settings, err := s.schema.GetTableSettings(tableName)
+	rowsTable, params, err := s.tableViewParams(table, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.getRowsPage(rowsTable, params)
 
 	if err != nil {
 		return nil, err
 	}
 
+	total, err := s.countRows(rowsTable, params.Filters, params.CountMode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := table.GetColumns(params.SelectColumns)
+
+	return &TableView{
+		Rows:       page.Rows,
+		Columns:    columns,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		Total:      total,
+		Versions:   page.Versions,
+	}, nil
+}
+
+// tableViewParams applies table view defaults and saved settings to params,
+// returns the table to query rows from
+func (s DataService) tableViewParams(table *Table, params GetRowsParams) (*Table, GetRowsParams, error) {
+	settings, err := s.schema.GetTableSettings(table.Key)
+
+	if err != nil {
+		return nil, params, err
+	}
+
 	// Apply defaults for Table View
 
-	if params.Sorting.IsEmpty() {
+	if params.Filters.TextSearch != nil {
+		params.Filters.TextSearch.Cols = settings.TableViewTextFiltersCols
+	}
+
+	// ranked search results are ordered by relevance
+	if params.Sorting.IsEmpty() && s.searchRankSQL(table, params.Filters) == "" {
 		params.Sorting = DefaultTableSorting(table)
 	}
 
@@ -425,26 +1554,19 @@ func (s DataService) GetTableView(tableName string, params GetRowsParams) (*Tabl
 		params.SelectColumns = settings.TableViewSelectColumns
 	}
 
-	if params.Filters.TextSearch != nil {
-		params.Filters.TextSearch.Cols = settings.TableViewTextFiltersCols
-	}
-
-	rows, err := s.GetRows(tableName, params)
-
-	if err != nil {
-		return nil, err
+	// labels are returned in rows next to foreign key values
+	rowsTable := table
+	if params.WithLabels {
+		rowsTable = s.labeledTable(table)
+		params.SelectColumns = withLabelColumns(rowsTable, params.SelectColumns)
 	}
 
-	columns := table.GetColumns(params.SelectColumns)
-
-	return &TableView{
-		Rows:    rows,
-		Columns: columns,
-	}, nil
+	return rowsTable, params, nil
 }
 
 type FormView struct {
 	Rows          json.RawMessage `json:"rows,omitempty"`
+	Version       string          `json:"version,omitempty"`
 	TableSettings TableSettings   `json:"tableSettings"`
 }
 
@@ -463,7 +1585,7 @@ func (s DataService) GetFormView(tableName string, filters Filters, mode FormVie
 		return res, nil
 	}
 
-	rows, err := s.GetRows(tableName, GetRowsParams{
+	page, err := s.GetRowsPage(tableName, GetRowsParams{
 		Filters:    filters,
 		Pagination: Pagination{Limit: 1},
 	})
@@ -472,6 +1594,10 @@ func (s DataService) GetFormView(tableName string, filters Filters, mode FormVie
 		return nil, err
 	}
 
-	res.Rows = rows
+	res.Rows = page.Rows
+	if len(page.Versions) > 0 {
+		res.Version = page.Versions[0]
+	}
+
 	return res, nil
 }
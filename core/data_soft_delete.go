@@ -0,0 +1,133 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ---------------------- Soft Delete -------------------------------
+
+var ErrSoftDeleteNotConfigured = errors.New("soft delete is not configured")
+
+// SoftDelete marks rows as deleted by setting the column (e.g. deleted_at or is_deleted).
+// Row is deleted when timestamp column is not NULL or boolean column is true.
+type SoftDelete struct {
+	Column *Column
+	Value  string
+}
+
+func (sd SoftDelete) isBool() bool {
+	return sd.Column.UdtName == "bool"
+}
+
+func (sd SoftDelete) DeletedSQL() string {
+	if sd.isBool() {
+		return sd.Column.SafeName() + " IS TRUE"
+	}
+
+	return sd.Column.SafeName() + " IS NOT NULL"
+}
+
+func (sd SoftDelete) NotDeletedSQL() string {
+	if sd.isBool() {
+		return sd.Column.SafeName() + " IS NOT TRUE"
+	}
+
+	return sd.Column.SafeName() + " IS NULL"
+}
+
+// values of deleted rows used as SQL as is, any other value is bound as a parameter
+var softDeleteSQLValues = []string{"now()", "current_timestamp", "true"}
+
+// returns value of the column of deleted rows, bound value is appended to args as the next parameter
+func (sd SoftDelete) ValueSQL(args []any) (string, []any) {
+	if slices.Contains(softDeleteSQLValues, strings.ToLower(strings.TrimSpace(sd.Value))) {
+		return sd.Value, args
+	}
+
+	args = append(slices.Clone(args), sd.Value)
+	return fmt.Sprintf("CAST($%d AS %s)", len(args), sd.Column.RegType), args
+}
+
+// value of the column of restored rows
+func (sd SoftDelete) RestoreValue() string {
+	if sd.isBool() {
+		return "false"
+	}
+
+	return "NULL"
+}
+
+// returns soft delete from table settings, nil if not configured
+func (s DataService) softDelete(table *Table) *SoftDelete {
+	settings, err := s.schema.GetTableSettings(table.Key)
+	if err != nil || settings.SoftDeleteColumn == "" {
+		return nil
+	}
+
+	col, ok := table.GetColumn(settings.SoftDeleteColumn)
+	if !ok {
+		return nil
+	}
+
+	sd := &SoftDelete{Column: col, Value: settings.SoftDeleteValue}
+
+	if sd.Value == "" {
+		sd.Value = "now()"
+		if sd.isBool() {
+			sd.Value = "true"
+		}
+	}
+
+	return sd
+}
+
+var softDeleteRowsSQL = SqlT(`
+	UPDATE {{.TableName}}
+	SET {{.Column}} = {{.Value}}
+	{{.Where}}
+	RETURNING *
+`)
+
+// RestoreRows brings back soft-deleted rows matched by filters
+func (s DataService) RestoreRows(tableName string, filters Filters) (json.RawMessage, error) {
+	table, err := s.getWritableTable(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sd := s.softDelete(table)
+	if sd == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSoftDeleteNotConfigured, table.Key)
+	}
+
+	where, args, err := s.userFiltersToSQL(table, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(where) == 0 {
+		return nil, errors.New("can't restore rows with empty filters")
+	}
+
+	where = AndWhere(where, sd.DeletedSQL())
+
+	sql := softDeleteRowsSQL.Exec(map[string]any{
+		"TableName": table.SafeName(),
+		"Column":    sd.Column.SafeName(),
+		"Value":     sd.RestoreValue(),
+		"Where":     where,
+	})
+
+	return s.queryAudited(table, auditedStatement{
+		operation: AuditRestore,
+		sql:       sql,
+		args:      args,
+		where:     where,
+		whereArgs: args,
+	})
+}
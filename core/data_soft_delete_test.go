@@ -0,0 +1,44 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSoftDeleteValueSQL(t *testing.T) {
+	deletedAt := &Column{Name: "deleted_at", UdtName: "timestamptz", RegType: "timestamp with time zone"}
+	isDeleted := &Column{Name: "is_deleted", UdtName: "bool", RegType: "boolean"}
+	status := &Column{Name: "status", UdtName: "text", RegType: "text"}
+
+	tests := []struct {
+		name  string
+		sd    SoftDelete
+		value string
+		args  []any
+	}{
+		{"now", SoftDelete{Column: deletedAt, Value: "now()"}, "now()", []any{"1"}},
+		{"current timestamp", SoftDelete{Column: deletedAt, Value: "CURRENT_TIMESTAMP"}, "CURRENT_TIMESTAMP", []any{"1"}},
+		{"true", SoftDelete{Column: isDeleted, Value: "true"}, "true", []any{"1"}},
+		{"bound value", SoftDelete{Column: status, Value: "deleted"}, "CAST($2 AS text)", []any{"1", "deleted"}},
+		{"expression is bound", SoftDelete{Column: status, Value: "(SELECT 'x')"}, "CAST($2 AS text)", []any{"1", "(SELECT 'x')"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whereArgs := []any{"1"}
+			value, args := tt.sd.ValueSQL(whereArgs)
+
+			if value != tt.value {
+				t.Errorf("value = %s, want %s", value, tt.value)
+			}
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+
+			if len(whereArgs) != 1 {
+				t.Errorf("where args were modified: %#v", whereArgs)
+			}
+		})
+	}
+}
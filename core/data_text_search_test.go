@@ -0,0 +1,101 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTextSearchAutoMode(t *testing.T) {
+	table := testTable()
+
+	tests := []struct {
+		name   string
+		search TextSearchFilters
+		want   TextSearchMode
+	}{
+		{
+			name:   "search vector",
+			search: TextSearchFilters{Vector: &TextSearchVector{Expression: `"tsv"`}, TrigramSchema: "public"},
+			want:   TextSearchModeFullText,
+		},
+		{
+			name:   "trigram index on searched column",
+			search: TextSearchFilters{Cols: []string{"title"}, TrigramSchema: "public"},
+			want:   TextSearchModeTrigram,
+		},
+		{
+			name:   "column without trigram index",
+			search: TextSearchFilters{TrigramSchema: "public"},
+			want:   TextSearchModeILike,
+		},
+		{
+			name:   "pg_trgm isn't installed",
+			search: TextSearchFilters{Cols: []string{"title"}},
+			want:   TextSearchModeILike,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.search.autoMode(table); got != tt.want {
+				t.Errorf("mode = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextSearchToSQL(t *testing.T) {
+	table := testTable()
+
+	tests := []struct {
+		name   string
+		search TextSearchFilters
+		where  string
+		args   []any
+		rank   string
+	}{
+		{
+			name:   "ilike",
+			search: TextSearchFilters{Text: "Go", Cols: []string{"title", "body"}, Mode: TextSearchModeILike},
+			where:  `WHERE "title"::text ILIKE $1 OR "body"::text ILIKE $1`,
+			args:   []any{"%go%"},
+		},
+		{
+			name:   "trigram",
+			search: TextSearchFilters{Text: "Go", Cols: []string{"title"}, Mode: TextSearchModeTrigram, TrigramSchema: "ext"},
+			where:  `WHERE "title"::text ILIKE '%' || $1::text || '%'`,
+			args:   []any{"Go"},
+			rank:   `GREATEST("ext".similarity("title"::text, $1::text))`,
+		},
+		{
+			name:   "fulltext",
+			search: TextSearchFilters{Text: "go", Mode: TextSearchModeFullText, Config: "english", Vector: &TextSearchVector{Expression: `"tsv"`}},
+			where:  `WHERE "tsv" @@ websearch_to_tsquery('english'::regconfig, $1::text)`,
+			args:   []any{"go"},
+			rank:   `ts_rank("tsv", websearch_to_tsquery('english'::regconfig, $1::text))`,
+		},
+		{
+			name:   "no columns",
+			search: TextSearchFilters{Text: "go", Cols: []string{"id2"}, Mode: TextSearchModeILike},
+			where:  "WHERE FALSE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := tt.search.ToSQL(table)
+
+			if where != tt.where {
+				t.Errorf("where = %s, want %s", where, tt.where)
+			}
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+
+			if rank := tt.search.RankSQL(table); rank != tt.rank {
+				t.Errorf("rank = %s, want %s", rank, tt.rank)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package core
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+// testTable is the table shared by SQL builder tests
+func testTable() *Table {
+	return &Table{
+		Key:    "public.items",
+		Name:   "items",
+		Schema: "public",
+		Kind:   TableKindTable,
+		Columns: []Column{
+			{Name: "id", OID: pgtype.Int4OID, UdtName: "int4", RegType: "integer", IsPrimaryKey: true},
+			{Name: "name", UdtName: "text", RegType: "text", IsText: true},
+			{Name: "email", UdtName: "text", RegType: "text", IsText: true, IsNullable: true},
+			{Name: "status", UdtName: "text", RegType: "text", IsText: true},
+			{Name: "title", UdtName: "text", RegType: "text", IsText: true},
+			{Name: "body", UdtName: "text", RegType: "text", IsText: true, IsNullable: true},
+			{Name: "price", UdtName: "numeric", RegType: "numeric", IsNullable: true},
+			{Name: "tags", UdtName: "_text", RegType: "text[]", IsNullable: true},
+			{Name: "meta", UdtName: "jsonb", RegType: "jsonb", IsNullable: true},
+			{Name: "payload", UdtName: "json", RegType: "json", IsNullable: true},
+		},
+		UniqueKeys:     []UniqueKey{{Name: "items_pkey", Columns: []string{"id"}, IsPrimary: true}},
+		TrigramColumns: []string{"title"},
+	}
+}
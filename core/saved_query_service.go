@@ -0,0 +1,403 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ---------------------- Saved Queries -------------------------------
+
+type SavedQueryParamType string
+
+const (
+	ParamText      SavedQueryParamType = "text"
+	ParamInteger   SavedQueryParamType = "integer"
+	ParamNumeric   SavedQueryParamType = "numeric"
+	ParamBoolean   SavedQueryParamType = "boolean"
+	ParamDate      SavedQueryParamType = "date"
+	ParamTimestamp SavedQueryParamType = "timestamp"
+	ParamJSON      SavedQueryParamType = "json"
+)
+
+func (t SavedQueryParamType) IsValid() bool {
+	switch t {
+	case ParamText, ParamInteger, ParamNumeric, ParamBoolean, ParamDate, ParamTimestamp, ParamJSON:
+		return true
+	}
+
+	return false
+}
+
+var (
+	ErrNoSuchSavedQuery     = errors.New("no such saved query")
+	ErrInvalidSavedQuery    = errors.New("invalid saved query")
+	ErrSavedQueryNameInUse  = errors.New("saved query name is already in use")
+	ErrInvalidSavedQueryArg = errors.New("invalid saved query param value")
+)
+
+// SavedQueryParam describes a positional param of the query, the first one is $1
+type SavedQueryParam struct {
+	Name string              `json:"name"`
+	Type SavedQueryParamType `json:"type"`
+	// used when the value isn't passed, param without default is required
+	Default any `json:"default,omitempty"`
+}
+
+type SavedQuery struct {
+	ID          int64             `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Query       string            `json:"query"`
+	Params      []SavedQueryParam `json:"params"`
+	CreatedBy   string            `json:"createdBy"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+type SavedQueryRunRequest struct {
+	// param values by name, missing ones use defaults
+	Params map[string]any `json:"params"`
+	// client generated id to cancel the running query
+	QueryID string `json:"queryId,omitempty"`
+	// statement_timeout in milliseconds, limited by the server maximum
+	TimeoutMs int64 `json:"timeoutMs,omitempty"`
+	// run in a read only transaction that is always rolled back
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// run all statements of the script in a single transaction
+	Transaction bool `json:"transaction,omitempty"`
+}
+
+const savedQueryColumns = `id, name, description, query, params, created_by, created_at, updated_at`
+
+type SavedQueryService struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewSavedQueryService(db *pgxpool.Pool, logger *slog.Logger) *SavedQueryService {
+	return &SavedQueryService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (s *SavedQueryService) GetSavedQueries() ([]SavedQuery, error) {
+	rows, err := s.db.Query(context.Background(), `SELECT `+savedQueryColumns+` FROM pgpanel.saved_queries ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+
+	queries, err := pgx.CollectRows(rows, scanSavedQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	if queries == nil {
+		queries = []SavedQuery{}
+	}
+
+	return queries, nil
+}
+
+func (s *SavedQueryService) GetSavedQuery(id int64) (*SavedQuery, error) {
+	sql := `SELECT ` + savedQueryColumns + ` FROM pgpanel.saved_queries WHERE id = $1`
+
+	return s.queryOne(sql, id)
+}
+
+func (s *SavedQueryService) CreateSavedQuery(q SavedQuery, admin string) (*SavedQuery, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	sql := `
+		INSERT INTO pgpanel.saved_queries (name, description, query, params, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + savedQueryColumns
+
+	return s.queryOne(sql, q.Name, q.Description, q.Query, q.Params, admin)
+}
+
+func (s *SavedQueryService) UpdateSavedQuery(id int64, q SavedQuery) (*SavedQuery, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	sql := `
+		UPDATE pgpanel.saved_queries
+		SET name = $2, description = $3, query = $4, params = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING ` + savedQueryColumns
+
+	return s.queryOne(sql, id, q.Name, q.Description, q.Query, q.Params)
+}
+
+func (s *SavedQueryService) DeleteSavedQuery(id int64) error {
+	tag, err := s.db.Exec(context.Background(), `DELETE FROM pgpanel.saved_queries WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %d", ErrNoSuchSavedQuery, id)
+	}
+
+	return nil
+}
+
+func (s *SavedQueryService) queryOne(sql string, args ...any) (*SavedQuery, error) {
+	rows, err := s.db.Query(context.Background(), sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := pgx.CollectExactlyOneRow(rows, scanSavedQuery)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNoSuchSavedQuery
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrSavedQueryNameInUse
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &q, nil
+}
+
+func scanSavedQuery(row pgx.CollectableRow) (SavedQuery, error) {
+	var q SavedQuery
+
+	err := row.Scan(&q.ID, &q.Name, &q.Description, &q.Query, &q.Params, &q.CreatedBy, &q.CreatedAt, &q.UpdatedAt)
+
+	return q, err
+}
+
+func (q *SavedQuery) Validate() error {
+	if strings.TrimSpace(q.Name) == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidSavedQuery)
+	}
+
+	statements := SplitSQLStatements(q.Query)
+
+	if len(statements) == 0 {
+		return fmt.Errorf("%w: empty query", ErrInvalidSavedQuery)
+	}
+
+	if len(q.Params) > 0 && len(statements) > 1 {
+		return fmt.Errorf("%w: params can be used only with a single statement", ErrInvalidSavedQuery)
+	}
+
+	if q.Params == nil {
+		q.Params = []SavedQueryParam{}
+	}
+
+	if count := SQLParamsCount(q.Query); count != len(q.Params) {
+		return fmt.Errorf("%w: query uses %d params, %d are declared", ErrInvalidSavedQuery, count, len(q.Params))
+	}
+
+	var used []int
+	forEachSQLParam([]rune(q.Query), func(start, end, n int) {
+		used = append(used, n)
+	})
+
+	names := map[string]bool{}
+
+	for i, p := range q.Params {
+		if p.Name == "" {
+			return fmt.Errorf("%w: empty name of $%d param", ErrInvalidSavedQuery, i+1)
+		}
+
+		if names[p.Name] {
+			return fmt.Errorf("%w: duplicated param %s", ErrInvalidSavedQuery, p.Name)
+		}
+		names[p.Name] = true
+
+		// postgres can't infer type of the param that isn't used
+		if !slices.Contains(used, i+1) {
+			return fmt.Errorf("%w: $%d param %s isn't used", ErrInvalidSavedQuery, i+1, p.Name)
+		}
+
+		if !p.Type.IsValid() {
+			return fmt.Errorf("%w: unknown type %q of %s param", ErrInvalidSavedQuery, p.Type, p.Name)
+		}
+
+		if p.Default == nil {
+			continue
+		}
+
+		if _, err := p.Arg(p.Default); err != nil {
+			return fmt.Errorf("%w: default of %s param: %w", ErrInvalidSavedQuery, p.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// postgres types of the params, placeholders are cast to them on execution
+var savedQueryParamSQLTypes = map[SavedQueryParamType]string{
+	ParamText:      "text",
+	ParamInteger:   "bigint",
+	ParamNumeric:   "numeric",
+	ParamBoolean:   "boolean",
+	ParamDate:      "date",
+	ParamTimestamp: "timestamptz",
+	ParamJSON:      "jsonb",
+}
+
+// TypedQuery returns the query with placeholders cast to declared types, e.g. ($1::bigint),
+// so values are converted by params types instead of types inferred from the query
+func (q *SavedQuery) TypedQuery() string {
+	src := []rune(q.Query)
+
+	var b strings.Builder
+	last := 0
+
+	forEachSQLParam(src, func(start, end, n int) {
+		if n < 1 || n > len(q.Params) {
+			return
+		}
+
+		b.WriteString(string(src[last:start]))
+		fmt.Fprintf(&b, "(%s::%s)", string(src[start:end]), savedQueryParamSQLTypes[q.Params[n-1].Type])
+		last = end
+	})
+
+	b.WriteString(string(src[last:]))
+
+	return b.String()
+}
+
+// Args returns query args in params order, missing values are replaced with defaults
+func (q *SavedQuery) Args(values map[string]any) ([]any, error) {
+	for name := range values {
+		if !q.hasParam(name) {
+			return nil, fmt.Errorf("%w: unknown param %s", ErrInvalidSavedQueryArg, name)
+		}
+	}
+
+	args := make([]any, len(q.Params))
+
+	for i, p := range q.Params {
+		value, ok := values[p.Name]
+
+		if !ok {
+			if p.Default == nil {
+				return nil, fmt.Errorf("%w: %s param is required", ErrInvalidSavedQueryArg, p.Name)
+			}
+			value = p.Default
+		}
+
+		arg, err := p.Arg(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s param: %w", ErrInvalidSavedQueryArg, p.Name, err)
+		}
+
+		args[i] = arg
+	}
+
+	return args, nil
+}
+
+func (q *SavedQuery) hasParam(name string) bool {
+	for _, p := range q.Params {
+		if p.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Arg validates the value by param type and returns it in the postgres text format,
+// so the server converts it to the type of the placeholder. nil is passed as NULL.
+func (p SavedQueryParam) Arg(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	var str string
+
+	switch v := value.(type) {
+	case string:
+		str = v
+	case float64:
+		str = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		str = v.String()
+	case bool:
+		str = strconv.FormatBool(v)
+	default:
+		// objects and arrays are allowed only for json params
+		if p.Type != ParamJSON {
+			return nil, fmt.Errorf("unsupported value %v", value)
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		str = string(b)
+	}
+
+	switch p.Type {
+	case ParamText:
+		return str, nil
+
+	case ParamInteger:
+		n, err := strconv.ParseInt(strings.TrimSpace(str), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", str)
+		}
+		return strconv.FormatInt(n, 10), nil
+
+	case ParamNumeric:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err != nil {
+			return nil, fmt.Errorf("%q is not a number", str)
+		}
+		return strings.TrimSpace(str), nil
+
+	case ParamBoolean:
+		b, err := strconv.ParseBool(strings.TrimSpace(str))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", str)
+		}
+		return strconv.FormatBool(b), nil
+
+	case ParamDate:
+		if _, err := time.Parse(time.DateOnly, str); err != nil {
+			return nil, fmt.Errorf("%q is not a date (YYYY-MM-DD)", str)
+		}
+		return str, nil
+
+	case ParamTimestamp:
+		for _, layout := range []string{time.RFC3339Nano, time.DateTime, "2006-01-02T15:04:05", time.DateOnly} {
+			if _, err := time.Parse(layout, str); err == nil {
+				return str, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a timestamp", str)
+
+	case ParamJSON:
+		if !json.Valid([]byte(str)) {
+			return nil, fmt.Errorf("%q is not a valid json", str)
+		}
+		return str, nil
+	}
+
+	return nil, fmt.Errorf("unknown param type %q", p.Type)
+}
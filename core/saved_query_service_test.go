@@ -0,0 +1,150 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSavedQueryParamArg(t *testing.T) {
+	tests := []struct {
+		typ   SavedQueryParamType
+		value any
+		want  any
+		err   bool
+	}{
+		{ParamText, "hello", "hello", false},
+		{ParamText, json.Number("10"), "10", false},
+		{ParamText, nil, nil, false},
+		{ParamInteger, " 42 ", "42", false},
+		{ParamInteger, 42.0, "42", false},
+		{ParamInteger, json.Number("9007199254740993"), "9007199254740993", false},
+		{ParamInteger, "4.2", nil, true},
+		{ParamNumeric, "4.2", "4.2", false},
+		{ParamNumeric, "abc", nil, true},
+		{ParamBoolean, true, "true", false},
+		{ParamBoolean, "1", "true", false},
+		{ParamBoolean, "yes", nil, true},
+		{ParamDate, "2024-02-29", "2024-02-29", false},
+		{ParamDate, "29.02.2024", nil, true},
+		{ParamTimestamp, "2024-02-29T10:00:00Z", "2024-02-29T10:00:00Z", false},
+		{ParamTimestamp, "2024-02-29 10:00:00", "2024-02-29 10:00:00", false},
+		{ParamTimestamp, "yesterday", nil, true},
+		{ParamJSON, map[string]any{"a": 1.0}, `{"a":1}`, false},
+		{ParamJSON, `{"a":`, nil, true},
+		// objects are allowed only for json params
+		{ParamText, map[string]any{"a": 1.0}, nil, true},
+	}
+
+	for _, tt := range tests {
+		p := SavedQueryParam{Name: "p", Type: tt.typ}
+		got, err := p.Arg(tt.value)
+
+		if (err != nil) != tt.err {
+			t.Errorf("%s %#v: err = %v, want error %v", tt.typ, tt.value, err, tt.err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %#v = %#v, want %#v", tt.typ, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSavedQueryValidate(t *testing.T) {
+	limit := SavedQueryParam{Name: "limit", Type: ParamInteger, Default: 10.0}
+	email := SavedQueryParam{Name: "email", Type: ParamText}
+
+	tests := []struct {
+		name  string
+		query SavedQuery
+		err   bool
+	}{
+		{"no params", SavedQuery{Name: "q", Query: "SELECT 1; SELECT 2"}, false},
+		{"params", SavedQuery{Name: "q", Query: "SELECT * FROM users WHERE email = $2 LIMIT $1", Params: []SavedQueryParam{limit, email}}, false},
+		{"placeholders in literals and comments", SavedQuery{Name: "q", Query: "SELECT '$2', $$ $3 $$ -- $4\nLIMIT $1", Params: []SavedQueryParam{limit}}, false},
+		{"empty name", SavedQuery{Query: "SELECT 1"}, true},
+		{"empty query", SavedQuery{Name: "q", Query: " -- nothing"}, true},
+		{"undeclared param", SavedQuery{Name: "q", Query: "SELECT $1, $2", Params: []SavedQueryParam{limit}}, true},
+		{"unused param", SavedQuery{Name: "q", Query: "SELECT $2", Params: []SavedQueryParam{limit, email}}, true},
+		{"params with several statements", SavedQuery{Name: "q", Query: "SELECT $1; SELECT 2", Params: []SavedQueryParam{limit}}, true},
+		{"duplicated param", SavedQuery{Name: "q", Query: "SELECT $1, $2", Params: []SavedQueryParam{limit, limit}}, true},
+		{"unknown type", SavedQuery{Name: "q", Query: "SELECT $1", Params: []SavedQueryParam{{Name: "x", Type: "uuid"}}}, true},
+		{"invalid default", SavedQuery{Name: "q", Query: "SELECT $1", Params: []SavedQueryParam{{Name: "x", Type: ParamInteger, Default: "x"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+
+			if err != nil && !errors.Is(err, ErrInvalidSavedQuery) {
+				t.Errorf("expected ErrInvalidSavedQuery, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSavedQueryArgs(t *testing.T) {
+	q := SavedQuery{
+		Query: "SELECT * FROM users WHERE email = $1 LIMIT $2",
+		Params: []SavedQueryParam{
+			{Name: "email", Type: ParamText},
+			{Name: "limit", Type: ParamInteger, Default: 10.0},
+		},
+	}
+
+	args, err := q.Args(map[string]any{"email": "a@b.c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(args, []any{"a@b.c", "10"}) {
+		t.Errorf("args = %#v", args)
+	}
+
+	for _, values := range []map[string]any{{}, {"email": "a@b.c", "other": 1.0}, {"email": "a@b.c", "limit": "x"}} {
+		if _, err := q.Args(values); !errors.Is(err, ErrInvalidSavedQueryArg) {
+			t.Errorf("%v: expected ErrInvalidSavedQueryArg, got %v", values, err)
+		}
+	}
+}
+
+func TestSavedQueryTypedQuery(t *testing.T) {
+	q := SavedQuery{
+		Query: "SELECT '$1', data->$2 FROM t WHERE id = $1 -- $2",
+		Params: []SavedQueryParam{
+			{Name: "id", Type: ParamInteger},
+			{Name: "key", Type: ParamText},
+		},
+	}
+
+	want := "SELECT '$1', data->($2::text) FROM t WHERE id = ($1::bigint) -- $2"
+
+	if got := q.TypedQuery(); got != want {
+		t.Errorf("typed query = %s, want %s", got, want)
+	}
+}
+
+func TestSQLParamsCount(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      int
+	}{
+		{"SELECT 1", 0},
+		{"SELECT $1, $12", 12},
+		{"SELECT $body$ $3 $body$, $1", 1},
+		{`SELECT "$2", E'\' $3', $1 /* $4 /* $5 */ */`, 1},
+		{"SELECT a$1 FROM t", 0},
+	}
+
+	for _, tt := range tests {
+		if got := SQLParamsCount(tt.statement); got != tt.want {
+			t.Errorf("SQLParamsCount(%q) = %d, want %d", tt.statement, got, tt.want)
+		}
+	}
+}
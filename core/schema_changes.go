@@ -0,0 +1,268 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---------------------- Schema Changes (LISTEN / NOTIFY) -------------------------------
+
+const (
+	SchemaChangesChannel = "pgpanel_schema_changes"
+
+	// DDL commands of a migration usually come in bursts, so they are applied together
+	schemaChangesDebounce   = 500 * time.Millisecond
+	schemaChangesRetryDelay = 5 * time.Second
+)
+
+// Payload is a JSON array of changed tables keys, empty payload means full reload
+const installSchemaChangesTriggerSQL = `
+	CREATE OR REPLACE FUNCTION pgpanel.notify_schema_changes() RETURNS event_trigger
+	LANGUAGE plpgsql AS $$
+	DECLARE
+		payload text;
+	BEGIN
+		IF TG_EVENT = 'sql_drop' THEN
+			SELECT json_agg(DISTINCT d.schema_name || '.' || d.object_name)::text
+			INTO payload
+			FROM pg_event_trigger_dropped_objects() d
+			WHERE d.object_type IN ('table', 'view', 'materialized view');
+		ELSE
+			-- index changes are reported for the indexed table
+			SELECT json_agg(DISTINCT n.nspname || '.' || c.relname)::text
+			INTO payload
+			FROM pg_event_trigger_ddl_commands() cmd
+			LEFT JOIN pg_index ix ON ix.indexrelid = cmd.objid
+			JOIN pg_class c ON c.oid = COALESCE(ix.indrelid, cmd.objid)
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE cmd.classid = 'pg_class'::regclass;
+		END IF;
+
+		IF payload IS NULL THEN
+			RETURN;
+		END IF;
+
+		-- notification payload is limited to 8000 bytes
+		IF octet_length(payload) > 7900 THEN
+			payload := '';
+		END IF;
+
+		PERFORM pg_notify('pgpanel_schema_changes', payload);
+	END;
+	$$;
+
+	DROP EVENT TRIGGER IF EXISTS pgpanel_schema_changes_end;
+	CREATE EVENT TRIGGER pgpanel_schema_changes_end
+		ON ddl_command_end
+		EXECUTE FUNCTION pgpanel.notify_schema_changes();
+
+	DROP EVENT TRIGGER IF EXISTS pgpanel_schema_changes_drop;
+	CREATE EVENT TRIGGER pgpanel_schema_changes_drop
+		ON sql_drop
+		EXECUTE FUNCTION pgpanel.notify_schema_changes();
+`
+
+// InstallSchemaChangesTrigger creates event triggers that notify about DDL changes.
+// Event triggers can be created only by superuser.
+func (s *SchemaService) InstallSchemaChangesTrigger() error {
+	_, err := s.db.Exec(context.Background(), installSchemaChangesTriggerSQL)
+
+	return err
+}
+
+// ListenSchemaChanges reloads changed tables and their settings until ctx is done.
+// Connection errors are logged and listening is restarted.
+func (s *SchemaService) ListenSchemaChanges(ctx context.Context) {
+	for attempt := 0; ; attempt++ {
+		// changes could be missed while listener was disconnected
+		err := s.listenSchemaChanges(ctx, attempt > 0)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.logger.Error("schema changes listener failed", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(schemaChangesRetryDelay):
+		}
+	}
+}
+
+func (s *SchemaService) listenSchemaChanges(ctx context.Context, reload bool) error {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+SchemaChangesChannel); err != nil {
+		return err
+	}
+
+	if reload {
+		s.applySchemaChanges(ctx, nil, true)
+	}
+
+	payloads := make(chan string)
+	errc := make(chan error, 1)
+
+	go func() {
+		for {
+			n, err := conn.Conn().WaitForNotification(ctx)
+			if err != nil {
+				errc <- err
+				return
+			}
+
+			select {
+			case payloads <- n.Payload:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	var changedKeys []string
+	var fullReload bool
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case err := <-errc:
+			return err
+
+		case payload := <-payloads:
+			var keys []string
+			if err := json.Unmarshal([]byte(payload), &keys); err != nil {
+				fullReload = true
+			}
+			changedKeys = append(changedKeys, keys...)
+
+			if debounce == nil {
+				debounce = time.After(schemaChangesDebounce)
+			}
+
+		case <-debounce:
+			s.applySchemaChanges(ctx, changedKeys, fullReload)
+
+			changedKeys, fullReload, debounce = nil, false, nil
+		}
+	}
+}
+
+func (s *SchemaService) applySchemaChanges(ctx context.Context, keys []string, fullReload bool) {
+	if fullReload {
+		if err := s.loadTablesFromDB(); err != nil {
+			s.logger.Error("can't reload tables", "error", err)
+			return
+		}
+
+		if err := s.loadTableSettingsFromDB(); err != nil {
+			s.logger.Error("can't reload table settings", "error", err)
+			return
+		}
+
+		s.logger.Info("schema reloaded")
+		return
+	}
+
+	if err := s.reloadTables(ctx, keys); err != nil {
+		s.logger.Error("can't reload changed tables", "tables", keys, "error", err)
+	}
+}
+
+// reloadTables reloads (or removes dropped) tables with the given keys
+// if they belong to the loaded schemas or included tables
+func (s *SchemaService) reloadTables(ctx context.Context, keys []string) error {
+	schemaNames, err := s.resolveSchemaNames(ctx)
+	if err != nil {
+		return err
+	}
+
+	includedKeys := s.includedKeys()
+
+	var changedKeys []string
+	for _, key := range keys {
+		if slices.Contains(changedKeys, key) {
+			continue
+		}
+
+		schema, _, _ := strings.Cut(key, ".")
+
+		if len(includedKeys) > 0 && !slices.Contains(includedKeys, key) {
+			continue
+		}
+		if len(includedKeys) == 0 && !slices.Contains(schemaNames, schema) {
+			continue
+		}
+
+		changedKeys = append(changedKeys, key)
+	}
+
+	if len(changedKeys) == 0 {
+		return nil
+	}
+
+	// foreign keys of other tables may reference renamed or dropped columns of changed tables
+	changedKeys = append(changedKeys, s.getTablesMap().referencingKeys(changedKeys)...)
+
+	tables, err := s.queryTables(ctx, schemaNames, changedKeys)
+	if err != nil {
+		return err
+	}
+
+	settings, err := s.queryTableSettings(tables.Tables())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tablesMap := maps.Clone(s.tablesMap)
+
+	for _, key := range changedKeys {
+		delete(tablesMap, key)
+		delete(s.tableSettingsMap, key)
+	}
+
+	maps.Copy(tablesMap, tables)
+	maps.Copy(s.tableSettingsMap, settings)
+
+	s.tablesMap = tablesMap
+	s.loadedSchemas = schemaNames
+
+	s.logger.Info("schema changes applied", "tables", changedKeys)
+
+	return nil
+}
+
+// referencingKeys returns keys of tables (except the given ones) with foreign keys to the given tables
+func (tm TablesMap) referencingKeys(keys []string) []string {
+	var referencing []string
+
+	for key, table := range tm {
+		if slices.Contains(keys, key) {
+			continue
+		}
+
+		for _, fk := range table.ForeignKeys {
+			if slices.Contains(keys, fk.TableKey()) {
+				referencing = append(referencing, key)
+				break
+			}
+		}
+	}
+
+	slices.Sort(referencing)
+
+	return referencing
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferencingKeys(t *testing.T) {
+	fk := func(schema, table string) []ForeignKey {
+		return []ForeignKey{{TableSchema: schema, TableName: table}}
+	}
+
+	tablesMap := TablesMap{
+		"public.users":    {Key: "public.users"},
+		"public.orders":   {Key: "public.orders", ForeignKeys: fk("public", "users")},
+		"billing.refunds": {Key: "billing.refunds", ForeignKeys: fk("public", "orders")},
+		"public.comments": {Key: "public.comments", ForeignKeys: fk("public", "comments")},
+	}
+
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{"referenced table", []string{"public.users"}, []string{"public.orders"}},
+		{"changed tables are skipped", []string{"public.users", "public.orders"}, []string{"billing.refunds"}},
+		{"self reference", []string{"public.comments"}, nil},
+		{"not referenced table", []string{"billing.refunds"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tablesMap.referencingKeys(tt.keys); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
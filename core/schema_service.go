@@ -1,10 +1,15 @@
 package core
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -12,32 +17,54 @@ import (
 )
 
 var (
-	ErrUnknownTable = errors.New("unknown table")
+	ErrUnknownTable        = errors.New("unknown table")
+	ErrReadOnlyTable       = errors.New("table is read-only")
+	ErrNotMaterializedView = errors.New("table is not a materialized view")
 )
 
 type SchemaService struct {
+	// default schema, used for table names without schema
 	SchemaName string
-
-	db               *pgxpool.Pool
-	includedTables   []string
+	// configured schemas, "*" means all non-system schemas
+	SchemaNames []string
+	// allow raw SQL expressions in table settings (e.g. displayExpression)
+	AllowRawSQLSettings bool
+
+	db             *pgxpool.Pool
+	includedTables []string
+	logger         *slog.Logger
+
+	// tablesMap is replaced as a whole on reload, so readers can keep using the old one
+	mu               sync.RWMutex
+	loadedSchemas    []string
+	trigramSchema    string
 	tablesMap        TablesMap
 	tableSettingsMap TableSettingsMap
-	logger           *slog.Logger
 }
 
-func NewSchemaService(db *pgxpool.Pool, logger *slog.Logger, schemaName string, includedTables []string) (*SchemaService, error) {
-	if schemaName == "" {
-		schemaName = DefaultSchemaName
+func NewSchemaService(db *pgxpool.Pool, logger *slog.Logger, schemaNames []string, includedTables []string) (*SchemaService, error) {
+	if len(schemaNames) == 0 {
+		schemaNames = []string{DefaultSchemaName}
+	}
+
+	// first explicitly listed schema is the default one
+	schemaName := DefaultSchemaName
+	for _, name := range schemaNames {
+		if name != AllSchemas {
+			schemaName = name
+			break
+		}
 	}
 
-	r := SchemaService{
-		SchemaName: schemaName,
+	r := &SchemaService{
+		SchemaName:  schemaName,
+		SchemaNames: schemaNames,
 
 		db:               db,
 		includedTables:   includedTables,
+		logger:           logger,
 		tablesMap:        make(TablesMap),
 		tableSettingsMap: make(TableSettingsMap),
-		logger:           logger,
 	}
 
 	if err := r.loadTablesFromDB(); err != nil {
@@ -48,99 +75,177 @@ func NewSchemaService(db *pgxpool.Pool, logger *slog.Logger, schemaName string,
 		return nil, err
 	}
 
-	return &r, nil
+	return r, nil
+}
+
+// resolves "*" to all non-system schemas, so new schemas are picked up on reload
+func (s *SchemaService) resolveSchemaNames(ctx context.Context) ([]string, error) {
+	if !slices.Contains(s.SchemaNames, AllSchemas) {
+		return s.SchemaNames, nil
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT nspname
+		FROM pg_catalog.pg_namespace
+		WHERE nspname NOT IN ('pg_catalog', 'pg_toast', 'information_schema', 'pgpanel')
+			AND nspname NOT LIKE 'pg_temp_%'
+			AND nspname NOT LIKE 'pg_toast_temp_%'
+		ORDER BY nspname
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// table name without schema belongs to the default schema
+func (s *SchemaService) tableKey(name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+
+	return TableKey(s.SchemaName, name)
+}
+
+// included tables can be from any schema, all tables of loaded schemas are used otherwise
+func (s *SchemaService) includedKeys() []string {
+	keys := make([]string, len(s.includedTables))
+	for i, tableName := range s.includedTables {
+		keys[i] = s.tableKey(tableName)
+	}
+
+	return keys
 }
 
 func (s *SchemaService) loadTablesFromDB() error {
 	ctx := context.Background()
 
-	tablesMap := make(TablesMap)
+	schemaNames, err := s.resolveSchemaNames(ctx)
+	if err != nil {
+		return err
+	}
 
-	if len(s.includedTables) == 0 {
-		// Get all tables
-		rows, err := s.db.Query(ctx, `
-			SELECT table_name 
-			FROM information_schema.tables 
-			WHERE table_schema = $1 AND table_type = 'BASE TABLE'
-		`, s.SchemaName)
-		if err != nil {
-			return err
-		}
-		defer rows.Close()
+	tablesMap, err := s.queryTables(ctx, schemaNames, s.includedKeys())
+	if err != nil {
+		return err
+	}
 
-		for rows.Next() {
-			var tableName string
-			if err := rows.Scan(&tableName); err != nil {
-				return err
-			}
-			tablesMap[tableName] = &Table{Name: tableName, Schema: s.SchemaName}
+	trigramSchema, err := s.queryTrigramSchema(ctx)
+	if err != nil {
+		return err
+	}
 
+	// set tableMap if there are no errors
+	s.mu.Lock()
+	s.tablesMap = tablesMap
+	s.loadedSchemas = schemaNames
+	s.trigramSchema = trigramSchema
+	s.mu.Unlock()
+
+	return nil
+}
+
+// queries tables of the schemas, or only tables with the given keys if not empty
+func (s *SchemaService) queryTables(ctx context.Context, schemaNames []string, keys []string) (TablesMap, error) {
+	tablesMap := make(TablesMap)
+
+	// Get tables, views and materialized views
+	tablesRows, err := s.db.Query(ctx, `
+		SELECT
+			n.nspname,
+			c.relname,
+			CASE c.relkind
+				WHEN 'v' THEN 'view'
+				WHEN 'm' THEN 'materializedView'
+				ELSE 'table'
+			END AS kind
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE
+			c.relkind IN ('r', 'p', 'v', 'm')
+			-- partitions are accessed through their parent table
+			AND NOT c.relispartition
+			AND (
+				(cardinality($2::text[]) = 0 AND n.nspname = ANY($1))
+				OR n.nspname || '.' || c.relname = ANY($2)
+			)
+	`, schemaNames, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer tablesRows.Close()
+
+	for tablesRows.Next() {
+		var table Table
+		if err := tablesRows.Scan(&table.Schema, &table.Name, &table.Kind); err != nil {
+			return nil, err
 		}
-	} else {
-		for _, tableName := range s.includedTables {
-			tablesMap[tableName] = &Table{Name: tableName, Schema: s.SchemaName}
-		}
+		table.Key = TableKey(table.Schema, table.Name)
+		tablesMap[table.Key] = &table
 	}
 
-	// Get ALL columns for all tables
+	// Get ALL columns for all tables (information_schema doesn't include materialized views)
 	rows, err := s.db.Query(ctx, `
 			SELECT 
-					table_name,
-					column_name,
-					udt_name::regtype::oid::int AS oid,
-					udt_name::regtype AS regtype,
-					udt_name,
-					is_nullable = 'YES' AS is_nullable,
-					column_default,
-					(
-						SELECT COUNT(*) > 0 
-						FROM information_schema.key_column_usage kcu
-						JOIN information_schema.table_constraints tc 
-							ON tc.constraint_name = kcu.constraint_name
-						WHERE 
-							tc.table_schema = c.table_schema
-							AND tc.table_name = c.table_name
-							AND tc.constraint_type = 'PRIMARY KEY'
-							AND kcu.column_name = c.column_name
+					n.nspname || '.' || c.relname AS table_key,
+					a.attname AS column_name,
+					t.oid::int AS oid,
+					t.oid::regtype AS regtype,
+					t.typname AS udt_name,
+					NOT a.attnotnull AS is_nullable,
+					CASE WHEN a.attgenerated = '' THEN pg_get_expr(ad.adbin, ad.adrelid) END AS column_default,
+					EXISTS (
+						SELECT 1
+						FROM pg_index ix
+						WHERE ix.indrelid = c.oid AND ix.indisprimary AND a.attnum = ANY(ix.indkey)
 					) AS is_primary_key,
 					(
 						SELECT json_build_object(
-							'tableName', cl.table_name,
-							'columnName', cl.column_name,
-							'constraintName', fk.constraint_name
+							'tableSchema', rn.nspname,
+							'tableName', rt.relname,
+							'columnName', ra.attname,
+							'constraintName', con.conname
 						)
-						FROM information_schema.constraint_column_usage AS cl
-						JOIN information_schema.referential_constraints fk 
-								ON cl.constraint_name = fk.unique_constraint_name 
-								AND cl.constraint_schema = fk.unique_constraint_schema
-						JOIN information_schema.key_column_usage AS kcu
-								ON kcu.constraint_name = fk.constraint_name
-								AND kcu.constraint_schema = fk.constraint_schema
-						WHERE 
-								kcu.table_schema = c.table_schema
-								AND kcu.table_name = c.table_name
-								AND kcu.column_name = c.column_name
-						
+						FROM pg_constraint con
+						JOIN pg_class rt ON rt.oid = con.confrelid
+						JOIN pg_namespace rn ON rn.oid = rt.relnamespace
+						CROSS JOIN LATERAL unnest(con.conkey, con.confkey) AS k(attnum, refattnum)
+						JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refattnum
+						WHERE
+								con.contype = 'f'
+								AND con.conrelid = c.oid
+								AND k.attnum = a.attnum
+						ORDER BY con.conname
+						LIMIT 1
 					) AS foreign_key_info
 				FROM 
-					information_schema.columns c
+					pg_attribute a
+				JOIN pg_class c ON c.oid = a.attrelid
+				JOIN pg_namespace n ON n.oid = c.relnamespace
+				JOIN pg_type at ON at.oid = a.atttypid
+				-- domains are reported by their base type
+				JOIN pg_type t ON t.oid = CASE WHEN at.typtype = 'd' THEN at.typbasetype ELSE at.oid END
+				LEFT JOIN pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
 				WHERE 
-					table_schema = $1 AND table_name = ANY($2)
+					a.attnum > 0
+					AND NOT a.attisdropped
+					AND n.nspname = ANY($1) AND n.nspname || '.' || c.relname = ANY($2)
 				ORDER BY 
-					ordinal_position ASC
-		`, s.SchemaName, tablesMap.Names())
+					a.attnum ASC
+		`, tablesMap.SchemaNames(), tablesMap.Names())
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var tableName string
+		var tableKey string
 		var col Column
 
 		if err := rows.Scan(
-			&tableName,
+			&tableKey,
 			&col.Name,
 			&col.OID,
 			&col.RegType,
@@ -150,36 +255,246 @@ func (s *SchemaService) loadTablesFromDB() error {
 			&col.IsPrimaryKey,
 			&col.ForeignKey,
 		); err != nil {
-			return err
+			return nil, err
 		}
 
 		col.IsText = col.OID == pgtype.VarcharOID || col.OID == pgtype.TextOID
 
-		tablesMap[tableName].Columns = append(tablesMap[tableName].Columns, col)
+		tablesMap[tableKey].Columns = append(tablesMap[tableKey].Columns, col)
 	}
 
-	// set tableMap if there are no errors
-	s.tablesMap = tablesMap
-	return nil
-}
+	// Get primary keys and unique constraints that can be used as ON CONFLICT targets
+	keysRows, err := s.db.Query(ctx, `
+			SELECT
+				n.nspname || '.' || t.relname AS table_key,
+				i.relname AS key_name,
+				ix.indisprimary,
+				array_agg(a.attname::text ORDER BY k.ord) AS columns
+			FROM pg_index ix
+			JOIN pg_class t ON t.oid = ix.indrelid
+			JOIN pg_namespace n ON n.oid = t.relnamespace
+			JOIN pg_class i ON i.oid = ix.indexrelid
+			CROSS JOIN LATERAL unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+			WHERE
+				n.nspname = ANY($1) AND n.nspname || '.' || t.relname = ANY($2)
+				AND ix.indisunique
+				AND ix.indpred IS NULL
+				AND ix.indexprs IS NULL
+				AND k.ord <= ix.indnkeyatts
+			GROUP BY n.nspname, t.relname, i.relname, ix.indisprimary
+			ORDER BY ix.indisprimary DESC, i.relname
+		`, tablesMap.SchemaNames(), tablesMap.Names())
 
-func (s *SchemaService) getTableSettingsFromDB(tableName string) (*TableSettings, error) {
-	table, err := s.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer keysRows.Close()
+
+	for keysRows.Next() {
+		var tableKey string
+		var key UniqueKey
+
+		if err := keysRows.Scan(&tableKey, &key.Name, &key.IsPrimary, &key.Columns); err != nil {
+			return nil, err
+		}
+
+		tablesMap[tableKey].UniqueKeys = append(tablesMap[tableKey].UniqueKeys, key)
+	}
+
+	// Get foreign key constraints with all their columns
+	fkRows, err := s.db.Query(ctx, `
+			SELECT
+				n.nspname || '.' || c.relname AS table_key,
+				con.conname,
+				rn.nspname,
+				rt.relname,
+				array_agg(a.attname::text ORDER BY k.ord) AS columns,
+				array_agg(ra.attname::text ORDER BY k.ord) AS referenced_columns
+			FROM pg_constraint con
+			JOIN pg_class c ON c.oid = con.conrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			JOIN pg_class rt ON rt.oid = con.confrelid
+			JOIN pg_namespace rn ON rn.oid = rt.relnamespace
+			CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord)
+			JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+			JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refattnum
+			WHERE
+				con.contype = 'f'
+				AND n.nspname = ANY($1) AND n.nspname || '.' || c.relname = ANY($2)
+			GROUP BY n.nspname, c.relname, con.conname, rn.nspname, rt.relname
+			ORDER BY con.conname
+		`, tablesMap.SchemaNames(), tablesMap.Names())
 
 	if err != nil {
 		return nil, err
 	}
+	defer fkRows.Close()
+
+	for fkRows.Next() {
+		var tableKey string
+		var fk ForeignKey
+
+		if err := fkRows.Scan(&tableKey, &fk.ConstraintName, &fk.TableSchema, &fk.TableName, &fk.ColumnNames, &fk.RefColumnNames); err != nil {
+			return nil, err
+		}
+
+		table := tablesMap[tableKey]
+		for _, name := range fk.ColumnNames {
+			if col, ok := table.GetColumn(name); ok {
+				fk.Columns = append(fk.Columns, *col)
+			}
+		}
+
+		table.ForeignKeys = append(table.ForeignKeys, fk)
+	}
+
+	if err := s.detectSearchVectors(ctx, tablesMap); err != nil {
+		return nil, err
+	}
+
+	if err := s.detectTrigramIndexes(ctx, tablesMap); err != nil {
+		return nil, err
+	}
+
+	return tablesMap, nil
+}
+
+var tsvectorConfigRe = regexp.MustCompile(`^to_tsvector\('([^']+)'::regconfig`)
+
+// tsvector column is preferred, otherwise GIN index on to_tsvector(...) expression is used
+func (s *SchemaService) detectSearchVectors(ctx context.Context, tablesMap TablesMap) error {
+	for _, t := range tablesMap {
+		for _, col := range t.Columns {
+			if col.UdtName == "tsvector" {
+				t.SearchVector = &TextSearchVector{Expression: col.SafeName()}
+				break
+			}
+		}
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			n.nspname || '.' || t.relname AS table_key,
+			pg_get_expr(ix.indexprs, ix.indrelid) AS expression
+		FROM pg_index ix
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_am am ON am.oid = i.relam
+		WHERE
+			n.nspname = ANY($1) AND n.nspname || '.' || t.relname = ANY($2)
+			AND am.amname = 'gin'
+			AND ix.indnatts = 1
+			AND ix.indexprs IS NOT NULL
+			AND ix.indpred IS NULL
+		ORDER BY i.relname
+	`, tablesMap.SchemaNames(), tablesMap.Names())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tableKey, expression string
+
+		if err := rows.Scan(&tableKey, &expression); err != nil {
+			return err
+		}
+
+		t := tablesMap[tableKey]
+		if t.SearchVector != nil || !strings.HasPrefix(expression, "to_tsvector(") {
+			continue
+		}
+
+		t.SearchVector = &TextSearchVector{Expression: expression}
+		if match := tsvectorConfigRe.FindStringSubmatch(expression); match != nil {
+			t.SearchVector.Config = match[1]
+		}
+	}
+
+	return rows.Err()
+}
+
+// columns with pg_trgm GIN or GiST index, trigram search is selected automatically only for them
+func (s *SchemaService) detectTrigramIndexes(ctx context.Context, tablesMap TablesMap) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT DISTINCT
+			n.nspname || '.' || t.relname AS table_key,
+			a.attname
+		FROM pg_index ix
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		CROSS JOIN LATERAL unnest(ix.indkey::int2[], ix.indclass::oid[]) AS k(attnum, opclass)
+		JOIN pg_opclass oc ON oc.oid = k.opclass
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		WHERE
+			n.nspname = ANY($1) AND n.nspname || '.' || t.relname = ANY($2)
+			AND oc.opcname IN ('gin_trgm_ops', 'gist_trgm_ops')
+			AND ix.indpred IS NULL
+	`, tablesMap.SchemaNames(), tablesMap.Names())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tableKey, column string
+
+		if err := rows.Scan(&tableKey, &column); err != nil {
+			return err
+		}
+
+		t := tablesMap[tableKey]
+		t.TrigramColumns = append(t.TrigramColumns, column)
+	}
+
+	return rows.Err()
+}
+
+// returns schema of installed pg_trgm extension, empty if it's not installed
+func (s *SchemaService) queryTrigramSchema(ctx context.Context) (string, error) {
+	var schema string
+
+	err := s.db.QueryRow(ctx, `
+		SELECT n.nspname
+		FROM pg_extension e
+		JOIN pg_namespace n ON n.oid = e.extnamespace
+		WHERE e.extname = 'pg_trgm'
+	`).Scan(&schema)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+
+	return schema, err
+}
+
+func (s *SchemaService) TrigramSchema() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.trigramSchema
+}
+
+func (s *SchemaService) getTableSettingsFromDB(table *Table) (*TableSettings, error) {
+	// settings of the default schema tables could be stored by table name only
+	legacyKey := table.Key
+	if table.Schema == s.SchemaName {
+		legacyKey = table.Name
+	}
 
 	sql := `
 		SELECT config FROM pgpanel.settings
-		WHERE type = 'table_settings' AND key = $1
+		WHERE type = 'table_settings' AND key IN ($1, $2)
+		ORDER BY key = $1 DESC
 		LIMIT 1
 	`
 
 	var result TableSettings
 
-	row := s.db.QueryRow(context.Background(), sql, tableName)
-	err = row.Scan(&result)
+	row := s.db.QueryRow(context.Background(), sql, table.Key, legacyKey)
+	err := row.Scan(&result)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		// skip
@@ -202,31 +517,67 @@ func (s *SchemaService) getTableSettingsFromDB(tableName string) (*TableSettings
 	return &result, nil
 }
 
-func (s *SchemaService) loadTableSettingsFromDB() error {
-	for _, t := range s.tablesMap {
-		settings, err := s.getTableSettingsFromDB(t.Name)
+func (s *SchemaService) queryTableSettings(tables []*Table) (TableSettingsMap, error) {
+	settingsMap := make(TableSettingsMap)
+
+	for _, t := range tables {
+		settings, err := s.getTableSettingsFromDB(t)
 
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		s.tableSettingsMap[t.Name] = settings
+		settingsMap[t.Key] = settings
+	}
+
+	return settingsMap, nil
+}
+
+func (s *SchemaService) loadTableSettingsFromDB() error {
+	settingsMap, err := s.queryTableSettings(s.getTablesMap().Tables())
+	if err != nil {
+		return err
 	}
 
+	s.mu.Lock()
+	s.tableSettingsMap = settingsMap
+	s.mu.Unlock()
+
 	return nil
 }
 
-func (s *SchemaService) GetTablesMap(reloadTables bool) TablesMap {
+func (s *SchemaService) getTablesMap() TablesMap {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tablesMap
+}
+
+func (s *SchemaService) GetTablesMap(reloadTables bool) (TablesMap, error) {
 	if reloadTables {
-		s.loadTablesFromDB()
+		if err := s.loadTablesFromDB(); err != nil {
+			return nil, err
+		}
+
+		if err := s.loadTableSettingsFromDB(); err != nil {
+			return nil, err
+		}
 	}
 
-	return s.tablesMap
+	return s.getTablesMap(), nil
 }
 
+// GetTable accepts schema qualified key (schema.table) or table name from the default schema
 func (s *SchemaService) GetTable(name string) (*Table, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	table := s.tablesMap[name]
 
+	if table == nil {
+		table = s.tablesMap[s.tableKey(name)]
+	}
+
 	if table == nil {
 		return nil, ErrUnknownTable
 	}
@@ -234,6 +585,38 @@ func (s *SchemaService) GetTable(name string) (*Table, error) {
 	return table, nil
 }
 
+// GetReferencingTables returns foreign keys of all loaded tables pointing at the table
+func (s *SchemaService) GetReferencingTables(tableName string) ([]TableReference, error) {
+	table, err := s.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []TableReference{}
+
+	for _, t := range s.getTablesMap().Tables() {
+		for _, fk := range t.GetForeignKeysByTable(table.Key) {
+			columns := make([]string, len(fk.Columns))
+			for i, col := range fk.Columns {
+				columns[i] = col.Name
+			}
+
+			refs = append(refs, TableReference{
+				Table:             t.Key,
+				Constraint:        fk.ConstraintName,
+				Columns:           columns,
+				ReferencedColumns: fk.ReferencedColumns(),
+			})
+		}
+	}
+
+	slices.SortFunc(refs, func(a, b TableReference) int {
+		return cmp.Or(strings.Compare(a.Table, b.Table), strings.Compare(a.Constraint, b.Constraint))
+	})
+
+	return refs, nil
+}
+
 func (s *SchemaService) CreateAdminTables() error {
 	sql := `
 		CREATE SCHEMA IF NOT EXISTS pgpanel;
@@ -256,8 +639,49 @@ func (s *SchemaService) CreateAdminTables() error {
 				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
+
+		CREATE TABLE IF NOT EXISTS pgpanel.audit_log (
+				id BIGSERIAL PRIMARY KEY,
+				admin TEXT,
+				table_key TEXT NOT NULL,
+				operation TEXT NOT NULL,
+				filters JSONB,
+				row_key JSONB,
+				before JSONB,
+				after JSONB,
+				created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS audit_log_table_key_idx ON pgpanel.audit_log (table_key, created_at);
+		CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON pgpanel.audit_log (created_at);
+
+		CREATE TABLE IF NOT EXISTS pgpanel.saved_queries (
+				id BIGSERIAL PRIMARY KEY,
+				name TEXT NOT NULL UNIQUE,
+				description TEXT NOT NULL DEFAULT '',
+				query TEXT NOT NULL,
+				params JSONB NOT NULL DEFAULT '[]'::jsonb,
+				created_by TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		);
 	`
-	_, err := s.db.Exec(context.Background(), sql)
+	if _, err := s.db.Exec(context.Background(), sql); err != nil {
+		return err
+	}
+
+	// table settings used to be stored by table name, move them to schema qualified keys
+	migrateSQL := `
+		UPDATE pgpanel.settings s
+		SET key = $1 || '.' || s.key
+		WHERE s.type = 'table_settings'
+			AND position('.' IN s.key) = 0
+			AND NOT EXISTS (
+				SELECT 1 FROM pgpanel.settings q
+				WHERE q.type = s.type AND q.key = $1 || '.' || s.key
+			)
+	`
+	_, err := s.db.Exec(context.Background(), migrateSQL, s.SchemaName)
 
 	return err
 }
@@ -303,7 +727,14 @@ func (s *SchemaService) GetSchemaNames() ([]string, error) {
 }
 
 func (s *SchemaService) GetTableSettings(tableName string) (*TableSettings, error) {
-	settings := s.tableSettingsMap[tableName]
+	table, err := s.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	settings := s.tableSettingsMap[table.Key]
+	s.mu.RUnlock()
 
 	if settings == nil {
 		return nil, ErrUnknownTable
@@ -312,33 +743,66 @@ func (s *SchemaService) GetTableSettings(tableName string) (*TableSettings, erro
 	return settings, nil
 }
 
-func (s *SchemaService) UpdateTableSettings(tableName string, updateSettings map[string]any) (*TableSettings, error) {
+// UpdateTableSettings merges settings and writes the change to the audit log on behalf of admin
+func (s *SchemaService) UpdateTableSettings(tableName string, updateSettings map[string]any, admin string) (*TableSettings, error) {
+	table, err := s.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateTableSettings(updateSettings); err != nil {
+		return nil, err
+	}
+
 	sql := `
-		INSERT INTO pgpanel.settings (type, key, config)
-		VALUES ('table_settings', $1, $2)
-		ON CONFLICT (type, key) 
-		DO UPDATE SET 
-    config = pgpanel.settings.config || EXCLUDED.config,
-    updated_at = CURRENT_TIMESTAMP
-		RETURNING config
+		WITH before AS (
+			SELECT config FROM pgpanel.settings
+			WHERE type = 'table_settings' AND key = $1
+		),
+		q AS (
+			INSERT INTO pgpanel.settings (type, key, config)
+			VALUES ('table_settings', $1, $2)
+			ON CONFLICT (type, key) 
+			DO UPDATE SET 
+			config = pgpanel.settings.config || EXCLUDED.config,
+			updated_at = CURRENT_TIMESTAMP
+			RETURNING config
+		),
+		audit AS (
+			INSERT INTO pgpanel.audit_log (admin, table_key, operation, before, after)
+			SELECT NULLIF($3::text, ''), $1, 'updateSettings', (SELECT config FROM before), q.config
+			FROM q
+		)
+		SELECT config FROM q
 	`
 
 	result := &TableSettings{}
 
-	row := s.db.QueryRow(context.Background(), sql, tableName, updateSettings)
-	err := row.Scan(&result)
+	row := s.db.QueryRow(context.Background(), sql, table.Key, updateSettings, admin)
+	err = row.Scan(&result)
 
 	if err != nil {
 		return nil, err
 	}
 
 	// update stored settings map
-	s.tableSettingsMap[tableName] = result
+	s.mu.Lock()
+	s.tableSettingsMap[table.Key] = result
+	s.mu.Unlock()
 
 	// and return it as well
 	return result, nil
 }
 
+// rejects settings with raw SQL expressions unless they are allowed
+func (s *SchemaService) validateTableSettings(updateSettings map[string]any) error {
+	if expr, ok := updateSettings["displayExpression"].(string); ok && expr != "" && !s.AllowRawSQLSettings {
+		return fmt.Errorf("%w: displayExpression requires raw SQL to be allowed, use displayColumn instead", ErrRawSQLFiltersForbidden)
+	}
+
+	return nil
+}
+
 func (s *SchemaService) DBName() string {
 	return s.db.Config().ConnConfig.Database
 }
@@ -347,31 +811,34 @@ func (s *SchemaService) GetStats() (*DatabaseSchemaStats, error) {
 	var stats DatabaseSchemaStats
 	stats.DBName = s.DBName()
 	stats.SchemaName = s.SchemaName
+	s.mu.RLock()
+	stats.SchemaNames = s.loadedSchemas
+	s.mu.RUnlock()
 
 	querySQL := `
 		WITH table_count AS (
 		    SELECT COUNT(*) as cnt 
 				FROM information_schema.tables 
-		    WHERE table_schema = $1 AND table_type = 'BASE TABLE'
+		    WHERE table_schema = ANY($1) AND table_type = 'BASE TABLE'
 		),
 		row_stats AS (
 		    SELECT COALESCE(SUM(n_live_tup), 0) as rows 
 				FROM pg_stat_user_tables 
-		    WHERE schemaname = $1
+		    WHERE schemaname = ANY($1)
 		),
 		size_stats AS (
 		    SELECT 
 		        COALESCE(SUM(pg_total_relation_size(c.oid)), 0) AS bytes
 		    FROM pg_class c 
 		    JOIN pg_namespace n ON n.oid = c.relnamespace
-		    WHERE n.nspname = $1
+		    WHERE n.nspname = ANY($1)
 		)
 		SELECT tc.cnt, rs.rows, ss.bytes, pg_size_pretty(ss.bytes)
 		FROM table_count tc, row_stats rs, size_stats ss;
   `
 
 	// Single round-trip to the DB
-	err := s.db.QueryRow(context.Background(), querySQL, s.SchemaName).Scan(
+	err := s.db.QueryRow(context.Background(), querySQL, stats.SchemaNames).Scan(
 		&stats.TablesCount,
 		&stats.TotalRows,
 		&stats.Size,
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateTableSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowRaw bool
+		settings map[string]any
+		err      error
+	}{
+		{"display column", false, map[string]any{"displayColumn": "name"}, nil},
+		{"display expression", false, map[string]any{"displayExpression": "first_name || ' ' || last_name"}, ErrRawSQLFiltersForbidden},
+		{"display expression reset", false, map[string]any{"displayExpression": ""}, nil},
+		{"display expression with raw SQL", true, map[string]any{"displayExpression": "first_name || ' ' || last_name"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SchemaService{AllowRawSQLSettings: tt.allowRaw}
+
+			if err := s.validateTableSettings(tt.settings); !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
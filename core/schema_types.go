@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"slices"
+	"strings"
 )
 
 type Column struct {
@@ -22,16 +23,73 @@ func (c *Column) SafeName() string {
 	return `"` + c.Name + `"`
 }
 
+func (c *Column) IsArray() bool {
+	return strings.HasPrefix(c.UdtName, "_")
+}
+
+func (c *Column) IsJSON() bool {
+	return c.UdtName == "json" || c.UdtName == "jsonb"
+}
+
 type ForeignKeyInfo struct {
+	TableSchema    string `json:"tableSchema"`
 	TableName      string `json:"tableName"`
 	ColumnName     string `json:"columnName"`
 	ConstraintName string `json:"constraintName"`
 }
 
+// Key of the referenced table in the TablesMap
+func (fk *ForeignKeyInfo) TableKey() string {
+	return TableKey(fk.TableSchema, fk.TableName)
+}
+
+type TableKind string
+
+const (
+	TableKindTable            TableKind = "table"
+	TableKindView             TableKind = "view"
+	TableKindMaterializedView TableKind = "materializedView"
+)
+
 type Table struct {
-	Name    string   `json:"name"`
-	Schema  string   `json:"schema"`
-	Columns []Column `json:"columns"`
+	// schema qualified name (schema.table) used to look up the table in the API and settings
+	Key        string      `json:"key"`
+	Name       string      `json:"name"`
+	Schema     string      `json:"schema"`
+	Kind       TableKind   `json:"kind"`
+	Columns    []Column    `json:"columns"`
+	UniqueKeys []UniqueKey `json:"uniqueKeys,omitempty"`
+	// foreign key constraints, ForeignKeyInfo of a column shows only one of them
+	ForeignKeys []ForeignKey `json:"foreignKeys,omitempty"`
+	// detected tsvector column or GIN index expression for full-text search
+	SearchVector *TextSearchVector `json:"searchVector,omitempty"`
+	// columns with pg_trgm index
+	TrigramColumns []string `json:"trigramColumns,omitempty"`
+
+	// FROM expression for tables extended with virtual columns, SafeName is used when empty
+	source string
+}
+
+type TextSearchVector struct {
+	Expression string `json:"expression"`
+	// text search config used by the index, empty if unknown
+	Config string `json:"config,omitempty"`
+}
+
+// Primary key or unique constraint (index) columns
+type UniqueKey struct {
+	Name      string   `json:"name"`
+	Columns   []string `json:"columns"`
+	IsPrimary bool     `json:"isPrimary"`
+}
+
+func TableKey(schema string, name string) string {
+	return schema + "." + name
+}
+
+// views and materialized views can be only read
+func (t *Table) IsReadOnly() bool {
+	return t.Kind != TableKindTable
 }
 
 // Safe name to use in SQL
@@ -39,6 +97,15 @@ func (t *Table) SafeName() string {
 	return fmt.Sprintf(`"%s"."%s"`, t.Schema, t.Name)
 }
 
+// FROM clause source to read rows
+func (t *Table) FromSQL() string {
+	if t.source != "" {
+		return t.source
+	}
+
+	return t.SafeName()
+}
+
 func (t *Table) SafeColumnNames() []string {
 	safeNames := make([]string, len(t.Columns))
 
@@ -82,6 +149,34 @@ func (t *Table) GetColumns(names []string) []Column {
 	return res
 }
 
+// PrimaryKey returns all primary key columns in the table order
+func (t *Table) PrimaryKey() []Column {
+	var cols []Column
+
+	for _, col := range t.Columns {
+		if col.IsPrimaryKey {
+			cols = append(cols, col)
+		}
+	}
+
+	return cols
+}
+
+// IsUniqueKey checks if columns set matches primary key or any unique constraint
+func (t *Table) IsUniqueKey(names []string) bool {
+	for _, key := range t.UniqueKeys {
+		if len(key.Columns) != len(names) {
+			continue
+		}
+
+		if !slices.ContainsFunc(names, func(n string) bool { return !slices.Contains(key.Columns, n) }) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (t *Table) GetTextColumns() []Column {
 	var cols []Column
 
@@ -108,7 +203,7 @@ func (t *Table) GetTextColumnsNames() []string {
 	return cols
 }
 
-func (t *Table) GetForeignKeyColumnByTable(foreignTableName string) (*Column, bool) {
+func (t *Table) GetForeignKeyColumnByTable(foreignTableKey string) (*Column, bool) {
 	for _, col := range t.Columns {
 		fk := col.ForeignKey
 
@@ -116,7 +211,7 @@ func (t *Table) GetForeignKeyColumnByTable(foreignTableName string) (*Column, bo
 			continue
 		}
 
-		if fk.TableName == foreignTableName {
+		if fk.TableKey() == foreignTableKey {
 			return &col, true
 		}
 	}
@@ -124,7 +219,7 @@ func (t *Table) GetForeignKeyColumnByTable(foreignTableName string) (*Column, bo
 	return nil, false
 }
 
-func (t *Table) GetForeignKeyColumnsByTable(foreignTableName string) []Column {
+func (t *Table) GetForeignKeyColumnsByTable(foreignTableKey string) []Column {
 	var fkCols []Column
 
 	for _, col := range t.Columns {
@@ -134,7 +229,7 @@ func (t *Table) GetForeignKeyColumnsByTable(foreignTableName string) []Column {
 			continue
 		}
 
-		if fk.TableName == foreignTableName {
+		if fk.TableKey() == foreignTableKey {
 			fkCols = append(fkCols, col)
 		}
 	}
@@ -142,6 +237,52 @@ func (t *Table) GetForeignKeyColumnsByTable(foreignTableName string) []Column {
 	return fkCols
 }
 
+// Foreign key constraint, can contain multiple columns (composite keys).
+// A column can belong to several constraints, e.g. tenant_id in (tenant_id, user_id) and (tenant_id, org_id).
+type ForeignKey struct {
+	ConstraintName string `json:"constraintName"`
+	// referenced table
+	TableSchema string `json:"tableSchema"`
+	TableName   string `json:"tableName"`
+	// column names and referenced column names in the constraint order
+	ColumnNames    []string `json:"columns"`
+	RefColumnNames []string `json:"referencedColumns"`
+
+	// constraint columns of the table in the constraint order
+	Columns []Column `json:"-"`
+}
+
+// Key of the referenced table in the TablesMap
+func (fk ForeignKey) TableKey() string {
+	return TableKey(fk.TableSchema, fk.TableName)
+}
+
+// ReferencedColumns returns names of referenced columns in the same order as Columns
+func (fk ForeignKey) ReferencedColumns() []string {
+	return fk.RefColumnNames
+}
+
+// GetForeignKeysByTable returns foreign key constraints referencing foreign table
+func (t *Table) GetForeignKeysByTable(foreignTableKey string) []ForeignKey {
+	var fks []ForeignKey
+
+	for _, fk := range t.ForeignKeys {
+		if fk.TableKey() == foreignTableKey {
+			fks = append(fks, fk)
+		}
+	}
+
+	return fks
+}
+
+// Foreign key of another table that references the table
+type TableReference struct {
+	Table             string   `json:"table"`
+	Constraint        string   `json:"constraint"`
+	Columns           []string `json:"columns"`
+	ReferencedColumns []string `json:"referencedColumns"`
+}
+
 // Map to easily look up stored tables
 type TablesMap map[string]*Table
 
@@ -155,6 +296,19 @@ func (m TablesMap) Names() []string {
 	return names
 }
 
+// unique schemas of stored tables
+func (m TablesMap) SchemaNames() []string {
+	var names []string
+
+	for _, t := range m {
+		if !slices.Contains(names, t.Schema) {
+			names = append(names, t.Schema)
+		}
+	}
+
+	return names
+}
+
 func (m TablesMap) Tables() []*Table {
 	var tables []*Table
 
@@ -174,6 +328,21 @@ type TableSettings struct {
 	TableViewTextFiltersCols []string            `json:"tableViewTextFiltersCols,omitempty"`
 	OverriddenInputs         OverriddenInputsMap `json:"overriddenInputs,omitempty"`
 	Relations                []RelationsConfig   `json:"relations,omitempty"`
+	// column used as row version token (e.g. updated_at), xmin is used when empty
+	VersionColumn string `json:"versionColumn,omitempty"`
+	// text search mode (ilike, fulltext, trigram), detected from indexes when empty
+	TextSearchMode   TextSearchMode `json:"textSearchMode,omitempty"`
+	TextSearchConfig string         `json:"textSearchConfig,omitempty"`
+	// label of the row shown in tables referencing this one,
+	// expression (e.g. first_name || ' ' || last_name) takes precedence over column,
+	// it's raw SQL, so it's allowed only together with raw SQL filters
+	DisplayColumn     string `json:"displayColumn,omitempty"`
+	DisplayExpression string `json:"displayExpression,omitempty"`
+	// soft delete: DeleteRows sets the column to the value (now() or true when empty)
+	// instead of deleting rows, soft-deleted rows are hidden unless requested.
+	// Only now(), CURRENT_TIMESTAMP and true are used as SQL, other values are bound as parameters
+	SoftDeleteColumn string `json:"softDeleteColumn,omitempty"`
+	SoftDeleteValue  string `json:"softDeleteValue,omitempty"`
 }
 
 type OverriddenInputsMap map[string]InputTypeLookup
@@ -196,10 +365,11 @@ type RelationsConfig struct {
 // DB stats related stuff
 
 type DatabaseSchemaStats struct {
-	DBName      string `json:"dbName"`
-	SchemaName  string `json:"schemaName"`
-	TablesCount int    `json:"tablesCount"`
-	TotalRows   int64  `json:"totalRows"`
-	Size        int64  `json:"size"`       // size in bytes
-	SizePretty  string `json:"sizePretty"` // human-readable
+	DBName      string   `json:"dbName"`
+	SchemaName  string   `json:"schemaName"`
+	SchemaNames []string `json:"schemaNames"`
+	TablesCount int      `json:"tablesCount"`
+	TotalRows   int64    `json:"totalRows"`
+	Size        int64    `json:"size"`       // size in bytes
+	SizePretty  string   `json:"sizePretty"` // human-readable
 }
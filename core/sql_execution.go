@@ -2,11 +2,11 @@ package core
 
 import (
 	"context"
-	"errors"
 	"strings"
 	"text/template"
+	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 )
 
 const (
@@ -16,27 +16,62 @@ const (
 type SQLExecutionRequest struct {
 	Query string `json:"query"`
 	Args  []any  `json:"args"`
+	// client generated id to cancel the running query
+	QueryID string `json:"queryId,omitempty"`
+	// statement_timeout in milliseconds, limited by the server maximum
+	TimeoutMs int64 `json:"timeoutMs,omitempty"`
+	// run in a read only transaction that is always rolled back
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// run all statements of the script in a single transaction
+	Transaction bool `json:"transaction,omitempty"`
 }
 
-type SQLExecutionResponse struct {
+// SQLStatementResult is a result of a single statement of the script
+type SQLStatementResult struct {
+	Statement    string           `json:"statement"`
 	Columns      []string         `json:"columns"`
 	Rows         []map[string]any `json:"rows"`
 	RowsAffected int64            `json:"rowsAffected"`
+	CommandTag   string           `json:"commandTag"`
+	DurationMs   float64          `json:"durationMs"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// SQLExecutionResponse contains results of executed statements in order,
+// statements after the failed one are not executed
+type SQLExecutionResponse struct {
+	Results []SQLStatementResult `json:"results"`
 }
 
-func (req *SQLExecutionRequest) Execute(db *pgxpool.Pool) (*SQLExecutionResponse, error) {
-	if len(req.Query) == 0 {
-		return nil, errors.New("empty query")
+type sqlQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func executeStatement(ctx context.Context, db sqlQuerier, statement string, args []any) SQLStatementResult {
+	result := SQLStatementResult{
+		Statement: statement,
+		Columns:   []string{},
+		Rows:      []map[string]any{},
 	}
 
-	ctx := context.Background()
-	rows, err := db.Query(ctx, req.Query, req.Args...)
+	started := time.Now()
+
+	if err := executeStatementRows(ctx, db, statement, args, &result); err != nil {
+		result.Error = err.Error()
+	}
+
+	result.DurationMs = float64(time.Since(started).Microseconds()) / 1000
+
+	return result
+}
+
+func executeStatementRows(ctx context.Context, db sqlQuerier, statement string, args []any, result *SQLStatementResult) error {
+	rows, err := db.Query(ctx, statement, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	results := make([]map[string]any, 0)
 	fieldDescriptions := rows.FieldDescriptions()
 
 	var rowsCount int
@@ -47,33 +82,32 @@ func (req *SQLExecutionRequest) Execute(db *pgxpool.Pool) (*SQLExecutionResponse
 
 		rowValues, err := rows.Values()
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		rowMap := make(map[string]any)
 		for i, val := range rowValues {
 			rowMap[fieldDescriptions[i].Name] = val
 		}
-		results = append(results, rowMap)
+		result.Rows = append(result.Rows, rowMap)
 
 		rowsCount += 1
 	}
 
 	// need to close rows before using CommandTag
 	rows.Close()
-	rowsAffected := rows.CommandTag().RowsAffected()
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-	columns := make([]string, len(fieldDescriptions))
+	result.RowsAffected = rows.CommandTag().RowsAffected()
+	result.CommandTag = rows.CommandTag().String()
 
-	for i, fd := range fieldDescriptions {
-		columns[i] = fd.Name
+	for _, fd := range fieldDescriptions {
+		result.Columns = append(result.Columns, fd.Name)
 	}
 
-	return &SQLExecutionResponse{
-		Columns:      columns,
-		Rows:         results,
-		RowsAffected: rowsAffected,
-	}, nil
+	return nil
 }
 
 // small utiliy to work with SQL temlates as STD Text Template
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// slowQuerier fails every query after the delay
+type slowQuerier struct {
+	delay time.Duration
+}
+
+func (q slowQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	time.Sleep(q.delay)
+	return nil, errors.New("query failed")
+}
+
+func TestExecuteStatementDuration(t *testing.T) {
+	result := executeStatement(context.Background(), slowQuerier{delay: 2 * time.Millisecond}, "SELECT 1", nil)
+
+	if result.Error != "query failed" {
+		t.Errorf("error = %q", result.Error)
+	}
+
+	if result.DurationMs <= 0 {
+		t.Errorf("duration = %v, want > 0", result.DurationMs)
+	}
+}
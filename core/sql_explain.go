@@ -0,0 +1,260 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ---------------------- SQL Explain -------------------------------
+
+const (
+	// node is expensive when it takes at least this share of the whole plan time (or cost)
+	ExpensivePlanNodeShare = 0.2
+	// node is misestimated when actual rows differ from estimated ones at least this many times
+	MisestimatedRowsFactor = 10
+)
+
+type SQLExplainRequest struct {
+	Query string `json:"query"`
+	Args  []any  `json:"args"`
+	// run the query with EXPLAIN ANALYZE in a transaction that is always rolled back
+	Analyze bool `json:"analyze,omitempty"`
+
+	// explain the rows query of the table instead of Query
+	Table string `json:"table,omitempty"`
+	// GetRows params of the table in the query string format, e.g. "filters=...&sort=..."
+	Params string `json:"params,omitempty"`
+	// apply table view defaults and saved settings to params
+	TableView bool `json:"tableView,omitempty"`
+
+	// client generated id to cancel the running query
+	QueryID string `json:"queryId,omitempty"`
+	// statement_timeout in milliseconds, limited by the server maximum
+	TimeoutMs int64 `json:"timeoutMs,omitempty"`
+}
+
+// PlanNode is a node of the plan tree, actual values are set only for ANALYZE
+type PlanNode struct {
+	NodeType     string `json:"nodeType"`
+	RelationName string `json:"relationName,omitempty"`
+	Alias        string `json:"alias,omitempty"`
+	IndexName    string `json:"indexName,omitempty"`
+
+	StartupCost float64 `json:"startupCost"`
+	TotalCost   float64 `json:"totalCost"`
+	// cost of the node without its children
+	ExclusiveCost float64 `json:"exclusiveCost"`
+	PlanRows      float64 `json:"planRows"`
+
+	// rows and times are per loop like in EXPLAIN output
+	ActualRows          *float64 `json:"actualRows,omitempty"`
+	ActualLoops         *float64 `json:"actualLoops,omitempty"`
+	ActualStartupTimeMs *float64 `json:"actualStartupTimeMs,omitempty"`
+	ActualTotalTimeMs   *float64 `json:"actualTotalTimeMs,omitempty"`
+	// time spent in the node itself for all loops, without children
+	ExclusiveTimeMs *float64 `json:"exclusiveTimeMs,omitempty"`
+	// actual rows divided by estimated rows
+	RowsEstimateFactor *float64 `json:"rowsEstimateFactor,omitempty"`
+
+	SharedHitBlocks  int64 `json:"sharedHitBlocks"`
+	SharedReadBlocks int64 `json:"sharedReadBlocks"`
+
+	Expensive    bool `json:"expensive"`
+	Misestimated bool `json:"misestimated"`
+
+	// all fields of the node from EXPLAIN output except child plans
+	Details map[string]any `json:"details"`
+	Plans   []*PlanNode    `json:"plans"`
+}
+
+type SQLExplainResponse struct {
+	Query           string          `json:"query"`
+	Args            []any           `json:"args"`
+	Plan            *PlanNode       `json:"plan"`
+	PlanningTimeMs  *float64        `json:"planningTimeMs,omitempty"`
+	ExecutionTimeMs *float64        `json:"executionTimeMs,omitempty"`
+	Raw             json.RawMessage `json:"raw"`
+	// plan of the total rows count query of the table view
+	Count *SQLExplainResponse `json:"count,omitempty"`
+}
+
+// Explain runs EXPLAIN (FORMAT JSON, BUFFERS) for a single statement.
+// With ANALYZE the statement is executed, so the transaction is always rolled back.
+func (s *SQLService) Explain(ctx context.Context, req *SQLExplainRequest) (*SQLExplainResponse, error) {
+	statements := SplitSQLStatements(req.Query)
+
+	if len(statements) != 1 {
+		return nil, errors.New("only a single statement can be explained")
+	}
+
+	options := "FORMAT JSON, BUFFERS"
+	if req.Analyze {
+		options = "ANALYZE, " + options
+	}
+
+	sql := fmt.Sprintf("EXPLAIN (%s) %s", options, statements[0])
+
+	var raw json.RawMessage
+
+	err := s.session(ctx, req.QueryID, req.TimeoutMs, func(conn *pgxpool.Conn) error {
+		accessMode := pgx.ReadWrite
+		if s.ForceReadOnly {
+			accessMode = pgx.ReadOnly
+		}
+
+		tx, err := conn.BeginTx(ctx, pgx.TxOptions{AccessMode: accessMode})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(context.Background())
+
+		return tx.QueryRow(ctx, sql, req.Args...).Scan(&raw)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := parseExplainOutput(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Query = statements[0]
+	res.Args = req.Args
+
+	return res, nil
+}
+
+func parseExplainOutput(raw json.RawMessage) (*SQLExplainResponse, error) {
+	var output []struct {
+		Plan          map[string]any `json:"Plan"`
+		PlanningTime  *float64       `json:"Planning Time"`
+		ExecutionTime *float64       `json:"Execution Time"`
+	}
+
+	if err := json.Unmarshal(raw, &output); err != nil {
+		return nil, fmt.Errorf("can't parse plan: %w", err)
+	}
+
+	if len(output) == 0 || output[0].Plan == nil {
+		return nil, errors.New("empty plan")
+	}
+
+	root := parsePlanNode(output[0].Plan)
+	highlightPlanNodes(root, root)
+
+	return &SQLExplainResponse{
+		Plan:            root,
+		PlanningTimeMs:  output[0].PlanningTime,
+		ExecutionTimeMs: output[0].ExecutionTime,
+		Raw:             raw,
+	}, nil
+}
+
+func parsePlanNode(raw map[string]any) *PlanNode {
+	str := func(key string) string {
+		v, _ := raw[key].(string)
+		return v
+	}
+	num := func(key string) float64 {
+		v, _ := raw[key].(float64)
+		return v
+	}
+	optNum := func(key string) *float64 {
+		if v, ok := raw[key].(float64); ok {
+			return &v
+		}
+		return nil
+	}
+
+	node := &PlanNode{
+		NodeType:            str("Node Type"),
+		RelationName:        str("Relation Name"),
+		Alias:               str("Alias"),
+		IndexName:           str("Index Name"),
+		StartupCost:         num("Startup Cost"),
+		TotalCost:           num("Total Cost"),
+		PlanRows:            num("Plan Rows"),
+		ActualRows:          optNum("Actual Rows"),
+		ActualLoops:         optNum("Actual Loops"),
+		ActualStartupTimeMs: optNum("Actual Startup Time"),
+		ActualTotalTimeMs:   optNum("Actual Total Time"),
+		SharedHitBlocks:     int64(num("Shared Hit Blocks")),
+		SharedReadBlocks:    int64(num("Shared Read Blocks")),
+		Details:             map[string]any{},
+		Plans:               []*PlanNode{},
+	}
+
+	for key, value := range raw {
+		if key != "Plans" {
+			node.Details[key] = value
+		}
+	}
+
+	children, _ := raw["Plans"].([]any)
+	for _, child := range children {
+		if child, ok := child.(map[string]any); ok {
+			node.Plans = append(node.Plans, parsePlanNode(child))
+		}
+	}
+
+	node.ExclusiveCost = node.TotalCost
+	for _, child := range node.Plans {
+		node.ExclusiveCost -= child.TotalCost
+	}
+	node.ExclusiveCost = math.Max(node.ExclusiveCost, 0)
+
+	if total, ok := node.totalTimeMs(); ok {
+		for _, child := range node.Plans {
+			if childTotal, ok := child.totalTimeMs(); ok {
+				total -= childTotal
+			}
+		}
+		exclusive := math.Max(total, 0)
+		node.ExclusiveTimeMs = &exclusive
+	}
+
+	if node.ActualRows != nil {
+		factor := *node.ActualRows / math.Max(node.PlanRows, 1)
+		node.RowsEstimateFactor = &factor
+	}
+
+	return node
+}
+
+// total time of the node for all loops
+func (n *PlanNode) totalTimeMs() (float64, bool) {
+	if n.ActualTotalTimeMs == nil || n.ActualLoops == nil {
+		return 0, false
+	}
+
+	return *n.ActualTotalTimeMs * *n.ActualLoops, true
+}
+
+// highlights nodes that take a big share of the plan time (or cost without ANALYZE)
+// and nodes with badly estimated rows
+func highlightPlanNodes(node *PlanNode, root *PlanNode) {
+	if rootTime, ok := root.totalTimeMs(); ok {
+		node.Expensive = rootTime > 0 && node.ExclusiveTimeMs != nil &&
+			*node.ExclusiveTimeMs >= rootTime*ExpensivePlanNodeShare
+	} else {
+		node.Expensive = root.TotalCost > 0 && node.ExclusiveCost >= root.TotalCost*ExpensivePlanNodeShare
+	}
+
+	// never executed nodes have zero loops, so they can't be compared with estimates
+	if node.ActualRows != nil && node.ActualLoops != nil && *node.ActualLoops > 0 {
+		actual, planned := *node.ActualRows+1, node.PlanRows+1
+		node.Misestimated = math.Max(actual, planned)/math.Min(actual, planned) >= MisestimatedRowsFactor
+	}
+
+	for _, child := range node.Plans {
+		highlightPlanNodes(child, root)
+	}
+}
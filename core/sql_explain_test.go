@@ -0,0 +1,122 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseExplainOutput(t *testing.T) {
+	raw := json.RawMessage(`[{
+		"Plan": {
+			"Node Type": "Limit", "Startup Cost": 0, "Total Cost": 100, "Plan Rows": 10,
+			"Plans": [
+				{"Node Type": "Seq Scan", "Relation Name": "users", "Alias": "u", "Startup Cost": 0, "Total Cost": 90, "Plan Rows": 1000,
+				 "Shared Hit Blocks": 5, "Shared Read Blocks": 2, "Filter": "(id > 1)"}
+			]
+		},
+		"Planning Time": 0.1
+	}]`)
+
+	res, err := parseExplainOutput(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := res.Plan
+	if root.NodeType != "Limit" || len(root.Plans) != 1 {
+		t.Fatalf("unexpected root %+v", root)
+	}
+
+	scan := root.Plans[0]
+
+	if scan.RelationName != "users" || scan.Alias != "u" || scan.SharedHitBlocks != 5 || scan.SharedReadBlocks != 2 {
+		t.Errorf("unexpected scan %+v", scan)
+	}
+
+	if scan.Details["Filter"] != "(id > 1)" {
+		t.Errorf("details = %v", scan.Details)
+	}
+
+	if _, ok := root.Details["Plans"]; ok {
+		t.Error("child plans are kept in details")
+	}
+
+	// without ANALYZE nodes are compared by cost
+	if root.ExclusiveCost != 10 || root.Expensive {
+		t.Errorf("root exclusive cost = %v, expensive = %v", root.ExclusiveCost, root.Expensive)
+	}
+
+	if scan.ExclusiveCost != 90 || !scan.Expensive || scan.Misestimated {
+		t.Errorf("scan exclusive cost = %v, expensive = %v, misestimated = %v", scan.ExclusiveCost, scan.Expensive, scan.Misestimated)
+	}
+
+	if res.PlanningTimeMs == nil || *res.PlanningTimeMs != 0.1 || res.ExecutionTimeMs != nil {
+		t.Errorf("planning = %v, execution = %v", res.PlanningTimeMs, res.ExecutionTimeMs)
+	}
+}
+
+func TestHighlightPlanNodesAnalyze(t *testing.T) {
+	raw := json.RawMessage(`[{
+		"Plan": {
+			"Node Type": "Nested Loop", "Total Cost": 50, "Plan Rows": 10,
+			"Actual Rows": 10, "Actual Loops": 1, "Actual Total Time": 10,
+			"Plans": [
+				{"Node Type": "Seq Scan", "Total Cost": 10, "Plan Rows": 10,
+				 "Actual Rows": 1000, "Actual Loops": 1, "Actual Total Time": 1},
+				{"Node Type": "Index Scan", "Total Cost": 30, "Plan Rows": 1,
+				 "Actual Rows": 1, "Actual Loops": 10, "Actual Total Time": 0.5},
+				{"Node Type": "Index Scan", "Total Cost": 5, "Plan Rows": 1000,
+				 "Actual Rows": 0, "Actual Loops": 0, "Actual Total Time": 0}
+			]
+		},
+		"Planning Time": 0.2,
+		"Execution Time": 10.5
+	}]`)
+
+	res, err := parseExplainOutput(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		node         *PlanNode
+		exclusiveMs  float64
+		expensive    bool
+		misestimated bool
+	}{
+		// 10 - 1 - 0.5 * 10 loops
+		{"nested loop", res.Plan, 4, true, false},
+		{"misestimated seq scan", res.Plan.Plans[0], 1, false, true},
+		{"index scan in loop", res.Plan.Plans[1], 5, true, false},
+		{"never executed", res.Plan.Plans[2], 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.node.ExclusiveTimeMs == nil || *tt.node.ExclusiveTimeMs != tt.exclusiveMs {
+				t.Errorf("exclusive time = %v, want %v", tt.node.ExclusiveTimeMs, tt.exclusiveMs)
+			}
+
+			if tt.node.Expensive != tt.expensive {
+				t.Errorf("expensive = %v, want %v", tt.node.Expensive, tt.expensive)
+			}
+
+			if tt.node.Misestimated != tt.misestimated {
+				t.Errorf("misestimated = %v, want %v", tt.node.Misestimated, tt.misestimated)
+			}
+		})
+	}
+
+	if res.ExecutionTimeMs == nil || *res.ExecutionTimeMs != 10.5 {
+		t.Errorf("execution time = %v", res.ExecutionTimeMs)
+	}
+}
+
+func TestParseExplainOutputInvalid(t *testing.T) {
+	for _, raw := range []string{`{}`, `[]`, `[{"Planning Time": 1}]`} {
+		if _, err := parseExplainOutput(json.RawMessage(raw)); err == nil {
+			t.Errorf("%s: expected error", raw)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const DefaultSQLMaxStatementTimeout = 5 * time.Minute
+
+var (
+	ErrNoSuchRunningQuery = errors.New("no such running query")
+	ErrQueryIDInUse       = errors.New("query id is already in use")
+	ErrReadOnlyEscape     = errors.New("transaction control statements aren't allowed in read only mode")
+)
+
+// SQLService executes queries from the SQL console
+type SQLService struct {
+	// upper limit of statement_timeout, zero means no limit
+	MaxStatementTimeout time.Duration
+	// all queries are executed in read only mode regardless of the request
+	ForceReadOnly bool
+
+	db     *pgxpool.Pool
+	logger *slog.Logger
+
+	mu sync.Mutex
+	// backend pids of running queries by query id
+	running map[string]uint32
+}
+
+func NewSQLService(db *pgxpool.Pool, logger *slog.Logger) *SQLService {
+	return &SQLService{
+		MaxStatementTimeout: DefaultSQLMaxStatementTimeout,
+
+		db:      db,
+		logger:  logger,
+		running: map[string]uint32{},
+	}
+}
+
+// statement timeout of the request limited by MaxStatementTimeout
+func (s *SQLService) statementTimeout(timeoutMs int64) time.Duration {
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+
+	if s.MaxStatementTimeout > 0 && (timeout <= 0 || timeout > s.MaxStatementTimeout) {
+		return s.MaxStatementTimeout
+	}
+
+	return max(timeout, 0)
+}
+
+// Execute runs statements of the script one by one on a dedicated connection, so they share the session
+// and can be cancelled by query id. Query is cancelled when ctx is done (e.g. client closed the request).
+func (s *SQLService) Execute(ctx context.Context, req *SQLExecutionRequest) (*SQLExecutionResponse, error) {
+	statements := SplitSQLStatements(req.Query)
+
+	if len(statements) == 0 {
+		return nil, errors.New("empty query")
+	}
+
+	if len(req.Args) > 0 && len(statements) > 1 {
+		return nil, errors.New("args can be used only with a single statement")
+	}
+
+	if req.ReadOnly || s.ForceReadOnly {
+		for _, statement := range statements {
+			if endsReadOnlyTransaction(statement) {
+				return nil, fmt.Errorf("%w: %s", ErrReadOnlyEscape, statement)
+			}
+		}
+	}
+
+	var res *SQLExecutionResponse
+
+	err := s.session(ctx, req.QueryID, req.TimeoutMs, func(conn *pgxpool.Conn) error {
+		var err error
+		res, err = s.execute(ctx, conn, req, statements)
+		return err
+	})
+
+	return res, err
+}
+
+func (s *SQLService) execute(ctx context.Context, conn *pgxpool.Conn, req *SQLExecutionRequest, statements []string) (*SQLExecutionResponse, error) {
+	var err error
+
+	readOnly := req.ReadOnly || s.ForceReadOnly
+
+	var db sqlQuerier = conn
+	var tx pgx.Tx
+
+	if readOnly || req.Transaction {
+		accessMode := pgx.ReadWrite
+		if readOnly {
+			accessMode = pgx.ReadOnly
+		}
+
+		tx, err = conn.BeginTx(ctx, pgx.TxOptions{AccessMode: accessMode})
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback(context.Background())
+
+		// read write mode can't be set back once the transaction took a snapshot
+		if _, err := tx.Exec(ctx, "SELECT 1"); err != nil {
+			return nil, err
+		}
+
+		db = tx
+	}
+
+	res := &SQLExecutionResponse{Results: []SQLStatementResult{}}
+	failed := false
+	timeout := s.statementTimeout(req.TimeoutMs)
+
+	for _, statement := range statements {
+		// script can't escape the read only (or single) transaction with COMMIT or ROLLBACK
+		if tx != nil && conn.Conn().PgConn().TxStatus() != 'T' {
+			res.Results = append(res.Results, SQLStatementResult{
+				Statement: statement,
+				Error:     "transaction has been ended by the previous statement",
+			})
+			failed = true
+			break
+		}
+
+		// script can't turn off the server maximum with SET statement_timeout
+		if s.MaxStatementTimeout > 0 {
+			if err := setStatementTimeout(ctx, db, timeout); err != nil {
+				res.Results = append(res.Results, SQLStatementResult{Statement: statement, Error: err.Error()})
+				failed = true
+				break
+			}
+		}
+
+		result := executeStatement(ctx, db, statement, req.Args)
+		res.Results = append(res.Results, result)
+
+		if result.Error != "" {
+			failed = true
+			break
+		}
+	}
+
+	if tx != nil && !readOnly && !failed {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// connection goes back to the pool, so transaction opened by the script can't be left
+	if tx == nil && conn.Conn().PgConn().TxStatus() != 'I' {
+		result := executeStatement(context.Background(), conn, "ROLLBACK", nil)
+		result.Error = "transaction opened by the script has not been committed and is rolled back"
+
+		res.Results = append(res.Results, result)
+	}
+
+	return res, nil
+}
+
+// reports statements that can end the read only transaction or change its access mode,
+// e.g. ROLLBACK AND CHAIN starts a new transaction that accepts SET TRANSACTION READ WRITE
+func endsReadOnlyTransaction(statement string) bool {
+	// setting can be changed by set_config with the name in a string literal as well
+	if strings.Contains(strings.ToLower(statement), "transaction_read_only") {
+		return true
+	}
+
+	// comments and literals are replaced with spaces, so only keywords are left
+	src := []rune(statement)
+	code := make([]rune, 0, len(src))
+	last := -1
+
+	scanSQL(src, func(i int, literal bool) {
+		if i > last+1 || literal {
+			code = append(code, ' ')
+		}
+		if !literal {
+			code = append(code, unicode.ToLower(src[i]))
+		}
+		last = i
+	})
+
+	words := strings.Fields(string(code))
+	if len(words) == 0 {
+		return false
+	}
+
+	normalized := strings.Join(words, " ")
+
+	switch words[0] {
+	case "commit", "end", "abort", "prepare":
+		return true
+	case "rollback":
+		// ROLLBACK TO SAVEPOINT keeps the transaction
+		for _, prefix := range []string{"rollback to ", "rollback work to ", "rollback transaction to "} {
+			if strings.HasPrefix(normalized, prefix) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return strings.Contains(normalized, "and chain") ||
+		strings.Contains(normalized, "set transaction") ||
+		strings.Contains(normalized, "set session characteristics")
+}
+
+// session runs fn on a dedicated connection with statement timeout,
+// the connection is registered by query id to be cancelled
+func (s *SQLService) session(ctx context.Context, queryID string, timeoutMs int64, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if queryID != "" {
+		if err := s.register(queryID, conn.Conn().PgConn().PID()); err != nil {
+			return err
+		}
+		defer s.unregister(queryID)
+	}
+
+	if err := setStatementTimeout(ctx, conn, s.statementTimeout(timeoutMs)); err != nil {
+		return err
+	}
+
+	defer func() {
+		// connection goes back to the pool, so it can't keep the timeout
+		if _, err := conn.Exec(context.Background(), "RESET statement_timeout"); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	return fn(conn)
+}
+
+// sets statement_timeout of the session, it's reset when the connection goes back to the pool
+func setStatementTimeout(ctx context.Context, db sqlQuerier, timeout time.Duration) error {
+	rows, err := db.Query(ctx, "SELECT set_config('statement_timeout', $1, false)", fmt.Sprint(timeout.Milliseconds()))
+	if err != nil {
+		return err
+	}
+	rows.Close()
+
+	return rows.Err()
+}
+
+func (s *SQLService) register(queryID string, pid uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.running[queryID]; ok {
+		return fmt.Errorf("%w: %s", ErrQueryIDInUse, queryID)
+	}
+
+	s.running[queryID] = pid
+	return nil
+}
+
+func (s *SQLService) unregister(queryID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.running, queryID)
+}
+
+// Cancel cancels the running query with pg_cancel_backend
+func (s *SQLService) Cancel(queryID string) error {
+	// connection can't go back to the pool (and run other queries) while it's being cancelled
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pid, ok := s.running[queryID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoSuchRunningQuery, queryID)
+	}
+
+	var cancelled bool
+	if err := s.db.QueryRow(context.Background(), "SELECT pg_cancel_backend($1)", int64(pid)).Scan(&cancelled); err != nil {
+		return err
+	}
+
+	if !cancelled {
+		return fmt.Errorf("can't cancel query %s", queryID)
+	}
+
+	s.logger.Info("query cancelled", "queryId", queryID, "pid", pid)
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// recordingQuerier records queries and returns no rows
+type recordingQuerier struct {
+	queries []string
+	args    [][]any
+}
+
+func (q *recordingQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	q.queries = append(q.queries, sql)
+	q.args = append(q.args, args)
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Close()                                       {}
+func (emptyRows) Err() error                                   { return nil }
+func (emptyRows) CommandTag() pgconn.CommandTag                { return pgconn.NewCommandTag("SELECT 0") }
+func (emptyRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (emptyRows) Next() bool                                   { return false }
+func (emptyRows) Scan(dest ...any) error                       { return nil }
+func (emptyRows) Values() ([]any, error)                       { return nil, nil }
+func (emptyRows) RawValues() [][]byte                          { return nil }
+func (emptyRows) Conn() *pgx.Conn                              { return nil }
+
+func TestStatementTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		max       time.Duration
+		timeoutMs int64
+		want      time.Duration
+	}{
+		{"server maximum by default", time.Minute, 0, time.Minute},
+		{"request timeout", time.Minute, 1000, time.Second},
+		{"request timeout is limited", time.Minute, 120_000, time.Minute},
+		{"no maximum", 0, 0, 0},
+		{"no maximum with request timeout", 0, 120_000, 2 * time.Minute},
+		{"negative request timeout", 0, -1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SQLService{MaxStatementTimeout: tt.max}
+
+			if got := s.statementTimeout(tt.timeoutMs); got != tt.want {
+				t.Errorf("timeout = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetStatementTimeout(t *testing.T) {
+	q := &recordingQuerier{}
+
+	if err := setStatementTimeout(context.Background(), q, 1500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(q.queries) != 1 || !reflect.DeepEqual(q.args[0], []any{"1500"}) {
+		t.Errorf("queries = %v, args = %v", q.queries, q.args)
+	}
+}
+
+func TestEndsReadOnlyTransaction(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      bool
+	}{
+		{"SELECT * FROM users", false},
+		{"SELECT 'commit', \"end\" FROM t -- rollback and chain", false},
+		{"ROLLBACK TO SAVEPOINT a", false},
+		{"rollback work to a", false},
+		{"SAVEPOINT a", false},
+		{"BEGIN", false},
+		// chained transaction doesn't have a snapshot, so it can be switched to read write
+		{"ROLLBACK AND CHAIN", true},
+		{"commit and chain", true},
+		{"COMMIT", true},
+		{"/* x */ Commit /* y */", true},
+		{"END", true},
+		{"abort", true},
+		{"ROLLBACK", true},
+		{"PREPARE TRANSACTION 'x'", true},
+		{"SET TRANSACTION READ WRITE", true},
+		{"set  transaction\nread write", true},
+		{"SET SESSION CHARACTERISTICS AS TRANSACTION READ WRITE", true},
+		{"SET transaction_read_only = off", true},
+		{"SET default_transaction_read_only = off", true},
+		{"SELECT set_config('transaction_read_only', 'off', true)", true},
+		{"SET/**/TRANSACTION READ WRITE", true},
+	}
+
+	for _, tt := range tests {
+		if got := endsReadOnlyTransaction(tt.statement); got != tt.want {
+			t.Errorf("endsReadOnlyTransaction(%q) = %v, want %v", tt.statement, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteRejectsReadOnlyEscape(t *testing.T) {
+	s := &SQLService{}
+
+	script := "ROLLBACK AND CHAIN; SET TRANSACTION READ WRITE; DELETE FROM t; COMMIT"
+
+	// rejected before a connection is acquired
+	_, err := s.Execute(context.Background(), &SQLExecutionRequest{Query: script, ReadOnly: true})
+	if !errors.Is(err, ErrReadOnlyEscape) {
+		t.Errorf("expected ErrReadOnlyEscape, got %v", err)
+	}
+
+	s.ForceReadOnly = true
+
+	_, err = s.Execute(context.Background(), &SQLExecutionRequest{Query: script})
+	if !errors.Is(err, ErrReadOnlyEscape) {
+		t.Errorf("expected ErrReadOnlyEscape with forced read only, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ---------------------- SQL Script Splitter -------------------------------
+
+// SplitSQLStatements splits script by semicolons outside of string literals,
+// quoted identifiers, dollar quoted strings and comments.
+// Statements that contain only whitespace and comments are skipped.
+func SplitSQLStatements(script string) []string {
+	var statements []string
+
+	src := []rune(script)
+	start := 0
+	// statement has something except whitespace and comments
+	hasCode := false
+
+	flush := func(end int) {
+		if hasCode {
+			statements = append(statements, strings.TrimSpace(string(src[start:end])))
+		}
+		start, hasCode = end+1, false
+	}
+
+	scanSQL(src, func(i int, literal bool) {
+		if !literal && src[i] == ';' {
+			flush(i)
+			return
+		}
+
+		if !unicode.IsSpace(src[i]) {
+			hasCode = true
+		}
+	})
+
+	flush(len(src))
+
+	return statements
+}
+
+// SQLParamsCount returns the highest positional param ($1, $2, ...) used by the statement
+func SQLParamsCount(statement string) int {
+	count := 0
+
+	forEachSQLParam([]rune(statement), func(start, end, n int) {
+		count = max(count, n)
+	})
+
+	return count
+}
+
+// calls fn for every positional param with its position in src and its number
+func forEachSQLParam(src []rune, fn func(start, end, n int)) {
+	scanSQL(src, func(i int, literal bool) {
+		if literal || src[i] != '$' || isIdentRune(at(src, i-1)) {
+			return
+		}
+
+		end := i + 1
+		for end < len(src) && unicode.IsDigit(src[end]) {
+			end++
+		}
+
+		if n, err := strconv.Atoi(string(src[i+1 : end])); err == nil {
+			fn(i, end, n)
+		}
+	})
+}
+
+// scanSQL calls fn for every rune outside of comments. String literals, quoted identifiers
+// and dollar quoted strings are passed once by their first rune as literal.
+func scanSQL(src []rune, fn func(i int, literal bool)) {
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+
+		switch {
+		case c == '-' && at(src, i+1) == '-':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+
+		case c == '/' && at(src, i+1) == '*':
+			// block comments can be nested
+			depth := 0
+			for ; i < len(src); i++ {
+				if src[i] == '/' && at(src, i+1) == '*' {
+					depth++
+					i++
+				} else if src[i] == '*' && at(src, i+1) == '/' {
+					depth--
+					i++
+					if depth == 0 {
+						break
+					}
+				}
+			}
+
+		case c == '\'':
+			fn(i, true)
+			// E'...' strings allow backslash escapes
+			escapes := (at(src, i-1) == 'E' || at(src, i-1) == 'e') && !isIdentRune(at(src, i-2))
+			i = skipQuoted(src, i, '\'', escapes)
+
+		case c == '"':
+			fn(i, true)
+			i = skipQuoted(src, i, '"', false)
+
+		case c == '$' && !isIdentRune(at(src, i-1)):
+			if tag, ok := dollarTag(src, i); ok {
+				fn(i, true)
+				i = indexRunes(src, tag, i+len(tag)) + len(tag) - 1
+				continue
+			}
+			fn(i, false)
+
+		default:
+			fn(i, false)
+		}
+	}
+}
+
+func at(src []rune, i int) rune {
+	if i < 0 || i >= len(src) {
+		return 0
+	}
+	return src[i]
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// returns index of the closing quote, doubled quote is an escaped one
+func skipQuoted(src []rune, i int, quote rune, backslashEscapes bool) int {
+	for i++; i < len(src); i++ {
+		switch {
+		case backslashEscapes && src[i] == '\\':
+			i++
+		case src[i] == quote && at(src, i+1) == quote:
+			i++
+		case src[i] == quote:
+			return i
+		}
+	}
+
+	return len(src)
+}
+
+// returns dollar quote tag like $$ or $body$, positional params like $1 aren't tags
+func dollarTag(src []rune, i int) ([]rune, bool) {
+	for j := i + 1; j < len(src); j++ {
+		c := src[j]
+
+		if c == '$' {
+			return src[i : j+1], true
+		}
+
+		if !(c == '_' || unicode.IsLetter(c) || (j > i+1 && unicode.IsDigit(c))) {
+			return nil, false
+		}
+	}
+
+	return nil, false
+}
+
+// returns index of the closing tag or the end of the script if it's not closed
+func indexRunes(src []rune, tag []rune, from int) int {
+	for i := from; i+len(tag) <= len(src); i++ {
+		if string(src[i:i+len(tag)]) == string(tag) {
+			return i
+		}
+	}
+
+	return len(src)
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "simple",
+			script: "SELECT 1; SELECT 2;",
+			want:   []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:   "no trailing semicolon",
+			script: "SELECT 1;\n  SELECT 2  ",
+			want:   []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:   "string literals and identifiers",
+			script: `SELECT 'a;b', 'it''s;' AS "x;y"; SELECT 2`,
+			want:   []string{`SELECT 'a;b', 'it''s;' AS "x;y"`, "SELECT 2"},
+		},
+		{
+			name:   "E-strings",
+			script: `SELECT E'\';', e'\\'; SELECT 2`,
+			want:   []string{`SELECT E'\';', e'\\'`, "SELECT 2"},
+		},
+		{
+			name:   "backslash isn't an escape in standard strings",
+			script: `SELECT 'a\'; SELECT 2`,
+			want:   []string{`SELECT 'a\'`, "SELECT 2"},
+		},
+		{
+			name:   "dollar quotes",
+			script: "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql; SELECT $body$ ; $$ ; $body$",
+			want: []string{
+				"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql",
+				"SELECT $body$ ; $$ ; $body$",
+			},
+		},
+		{
+			name:   "positional params aren't dollar quotes",
+			script: "SELECT $1; SELECT $2",
+			want:   []string{"SELECT $1", "SELECT $2"},
+		},
+		{
+			name:   "line comments",
+			script: "SELECT 1 -- one; two\n; SELECT 2",
+			want:   []string{"SELECT 1 -- one; two", "SELECT 2"},
+		},
+		{
+			name:   "nested block comments",
+			script: "SELECT /* a /* ; */ ; */ 1; SELECT 2",
+			want:   []string{"SELECT /* a /* ; */ ; */ 1", "SELECT 2"},
+		},
+		{
+			name:   "comment only statements are skipped",
+			script: "-- header\n; /* nothing */ ; SELECT 1;;",
+			want:   []string{"SELECT 1"},
+		},
+		{
+			name:   "unterminated literal",
+			script: "SELECT 'a; SELECT 2",
+			want:   []string{"SELECT 'a; SELECT 2"},
+		},
+		{
+			name:   "empty",
+			script: "  \n ",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitSQLStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+import { fetchApiwithAuth } from "@/lib/auth";
+import { Row } from "@/lib/pgTypes";
+
+export interface AuditEntry {
+  id: number;
+  admin: string;
+  table: string;
+  operation: "insert" | "upsert" | "update" | "delete" | "restore" | "revert" | "updateSettings" | "bulkInsert" | "import" | "refresh";
+  filters: { where: string; args?: any[] } | null;
+  rowKey: Row | null;
+  before: Row | null;
+  after: Row | null;
+  createdAt: string;
+}
+
+export interface AuditLogParams {
+  table?: string;
+  // primary key values, e.g. { id: 5 }
+  row?: Row;
+  admin?: string;
+  operation?: AuditEntry["operation"];
+  from?: string;
+  to?: string;
+  offset?: number;
+  limit?: number;
+}
+
+export async function getAuditLog(params: AuditLogParams) {
+  const s = new URLSearchParams();
+
+  for (const [key, value] of Object.entries(params)) {
+    if (value === undefined) continue;
+    s.set(key, typeof value === "object" ? JSON.stringify(value) : String(value));
+  }
+
+  const { data: entries = [], error } = await fetchApiwithAuth<AuditEntry[]>(`/api/audit?${s}`);
+
+  return { entries, error };
+}
+
+export interface RevertPreview {
+  entry: AuditEntry;
+  action: "update" | "insert" | "delete";
+  current: Row | null;
+  changes: { column: string; from: any; to: any }[];
+  // set when the row was changed after the entry
+  conflict?: string;
+}
+
+export async function previewRevert(entryId: number) {
+  const { data: preview, error } = await fetchApiwithAuth<RevertPreview>(
+    `/api/audit/${entryId}/revert`,
+  );
+
+  return { preview, error };
+}
+
+export async function revertChange(entryId: number) {
+  const { data: rows = [], error } = await fetchApiwithAuth<Row[]>(`/api/audit/${entryId}/revert`, {
+    method: "POST",
+  });
+
+  return { rows, error };
+}
@@ -11,8 +11,21 @@ export interface GetTableRowsParams {
   textFiltersCols?: string[];
   filters?: string;
   filtersArgs?: string;
+  where?: string;
+  cursor?: string;
+  count?: "auto" | "exact" | "estimated" | "none";
+  // add foreign keys display labels to rows as <column>:label fields
+  labels?: boolean;
+  // include soft-deleted rows
+  withDeleted?: boolean;
 }
 
+// Structured filters expression, see core.FilterExpr
+export type FilterExpr =
+  | { and: FilterExpr[] }
+  | { or: FilterExpr[] }
+  | { col: string; op: string; value?: any };
+
 type GetTableRowsParamsQK = keyof GetTableRowsParams;
 
 const fieldsDelimiter = "|";
@@ -30,12 +43,13 @@ export function parseQueryRowsParams(url: URL): GetTableRowsParams {
   const filters = url.searchParams.get("filters" satisfies GetTableRowsParamsQK) || undefined;
   const filtersArgs =
     url.searchParams.get("filtersArgs" satisfies GetTableRowsParamsQK) || undefined;
+  const where = url.searchParams.get("where" satisfies GetTableRowsParamsQK) || undefined;
 
   // Skip parsing of selectCols and textFiltersCols
   // They wont be used in UI query params
   // They will be applied from table settings in getTableView
 
-  return { offset, limit, sort, textFilters, filters, filtersArgs };
+  return { offset, limit, sort, textFilters, filters, filtersArgs, where };
 }
 
 export function paramsToURLSearchParams(params: Record<string, any>) {
@@ -52,14 +66,15 @@ export function paramsToURLSearchParams(params: Record<string, any>) {
 
 export async function getTableRow(
   tableName: string,
-  rowParams: Pick<GetTableRowsParams, "textFilters" | "filters" | "filtersArgs">,
+  rowParams: Pick<GetTableRowsParams, "textFilters" | "filters" | "filtersArgs" | "where">,
 ) {
-  const { textFilters, filters, filtersArgs } = rowParams;
+  const { textFilters, filters, filtersArgs, where } = rowParams;
 
   const s = paramsToURLSearchParams({
     textFilters,
     filters,
     filtersArgs,
+    where,
     offset: 0,
     limit: 1,
   });
@@ -78,6 +93,10 @@ export async function getTableRows(tableName: string, rowParams: GetTableRowsPar
 export interface TableView {
   rows: Row[];
   columns: PgColumn[];
+  nextCursor?: string;
+  prevCursor?: string;
+  total?: { count: number; estimated: boolean };
+  versions?: string[];
 }
 
 export async function getTableView(tableName: string, rowParams: GetTableRowsParams) {
@@ -91,18 +110,19 @@ export async function getTableView(tableName: string, rowParams: GetTableRowsPar
 
 export interface FormView {
   rows?: [Row];
+  version?: string;
   tableSettings: TableSettings;
 }
 
 export type FormViewMode = "insert" | "update";
 
-// pkeysFilters is just regular filters, but with primary keys
-export async function getFormView(tableName: string, mode: FormViewMode, pkeysFilters?: string) {
+// pkeysWhere is just regular structured filters, but with primary keys
+export async function getFormView(tableName: string, mode: FormViewMode, pkeysWhere?: string) {
   const s = new URLSearchParams();
   s.set("mode", mode);
 
-  if (pkeysFilters) {
-    s.set("filters", pkeysFilters);
+  if (pkeysWhere) {
+    s.set("where", pkeysWhere);
   }
 
   const { data, error } = await fetchApiwithAuth<FormView>(`/api/data/${tableName}/form-view?${s}`);
@@ -123,8 +143,10 @@ export async function updateTableRowByPKeys(
   pkeysMap: RowPkeysMap,
   updateFileds: any,
 ) {
+  const s = new URLSearchParams({ where: JSON.stringify(pkeysMapToWhere(pkeysMap)) });
+
   const { data: rows = [], error } = await fetchApiwithAuth<Row[]>(
-    `/api/data/${tableName}?filters=${pkeysMapToFilters(pkeysMap)}`,
+    `/api/data/${tableName}?${s}`,
     {
       method: "PUT",
       body: JSON.stringify(updateFileds),
@@ -135,10 +157,11 @@ export async function updateTableRowByPKeys(
 }
 
 export async function deleteTableRowsByPkeys(tableName: string, pkeys: RowPkeysMap[]) {
-  const filters = pkeys.map(pkeysMapToFilters).join(" OR ");
+  const where: FilterExpr = { or: pkeys.map(pkeysMapToWhere) };
+  const s = new URLSearchParams({ where: JSON.stringify(where) });
 
   const { data: rows = [], error } = await fetchApiwithAuth<Row[]>(
-    `/api/data/${tableName}?filters=${filters}`,
+    `/api/data/${tableName}?${s}`,
     {
       method: "DELETE",
     },
@@ -147,6 +170,35 @@ export async function deleteTableRowsByPkeys(tableName: string, pkeys: RowPkeysM
   return { rows, error };
 }
 
+export interface DeleteImpact {
+  table: string;
+  constraint: string;
+  columns: string[];
+  rule: "cascade" | "setNull" | "setDefault" | "restrict" | "noAction";
+  rows: number;
+  depth: number;
+}
+
+export interface DeletePreview {
+  rows: number;
+  impacts: DeleteImpact[];
+  changes: { table: string; deleted: number; updated: number }[];
+  blocked: boolean;
+  error?: string;
+  softDelete: boolean;
+}
+
+export async function previewDeleteTableRowsByPkeys(tableName: string, pkeys: RowPkeysMap[]) {
+  const where: FilterExpr = { or: pkeys.map(pkeysMapToWhere) };
+  const s = new URLSearchParams({ where: JSON.stringify(where) });
+
+  const { data: preview, error } = await fetchApiwithAuth<DeletePreview>(
+    `/api/data/${tableName}/delete-preview?${s}`,
+  );
+
+  return { preview, error };
+}
+
 export async function insertTableRow(tableName: string, row: any) {
   const { data: rows = [], error } = await fetchApiwithAuth<Row[]>(`/api/data/${tableName}`, {
     method: "POST",
@@ -190,12 +242,8 @@ export async function updateRelatedRows(
   return { status, error };
 }
 
-function pkeysMapToFilters(pkeysMap: RowPkeysMap) {
-  if (Object.keys(pkeysMap).length === 0) {
-    return "";
-  }
-
-  return Object.entries(pkeysMap)
-    .map(([key, value]) => `${key}=${value}`)
-    .join(" AND ");
+export function pkeysMapToWhere(pkeysMap: RowPkeysMap): FilterExpr {
+  return {
+    and: Object.entries(pkeysMap).map(([col, value]) => ({ col, op: "eq", value })),
+  };
 }
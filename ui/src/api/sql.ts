@@ -2,31 +2,187 @@ import { fetchApiwithAuth } from "@/lib/auth";
 import { ApiError } from "@/lib/fetchApi";
 import { Row } from "@/lib/pgTypes";
 
-export interface SQLExecutionResponse {
+export interface SQLStatementResult {
+  statement: string;
   columns: string[];
   rows: Row[];
   rowsAffected: number;
+  commandTag: string;
+  durationMs: number;
+  error?: string;
+}
+
+export interface SQLExecutionResponse {
+  results: SQLStatementResult[];
 }
 
 export interface SQLExecutionApiResponse {
-  sqlResponse?: SQLExecutionResponse;
+  // result of the last successful statement
+  sqlResponse?: SQLStatementResult;
+  results?: SQLStatementResult[];
   error?: ApiError;
 }
 
-export async function executeSQL(query: string, args?: any[]) {
-  const body = { query, args };
+export interface SQLExecutionOptions {
+  // client generated id, used to cancel the running query
+  queryId?: string;
+  timeoutMs?: number;
+  // run in a read only transaction that is always rolled back
+  readOnly?: boolean;
+  // run all statements of the script in a single transaction
+  transaction?: boolean;
+}
+
+export async function executeSQL(
+  query: string,
+  args?: any[],
+  options: SQLExecutionOptions = {},
+): Promise<SQLExecutionApiResponse> {
+  const body = { query, args, ...options };
 
-  const { data: sqlResponse, error } = await fetchApiwithAuth<SQLExecutionResponse>(
-    "/api/sql/execute",
-    {
-      method: "POST",
-      body: JSON.stringify(body),
-    },
-  );
+  const { data, error } = await fetchApiwithAuth<SQLExecutionResponse>("/api/sql/execute", {
+    method: "POST",
+    body: JSON.stringify(body),
+  });
 
   if (error) {
     return { error };
   }
 
-  return { sqlResponse };
+  const { results } = data;
+  const sqlResponse = [...results].reverse().find((r) => !r.error);
+  const failed = results.find((r) => r.error);
+
+  if (failed) {
+    return { sqlResponse, results, error: { code: 400, message: `${failed.statement}: ${failed.error}` } };
+  }
+
+  return { sqlResponse, results };
+}
+
+export interface PlanNode {
+  nodeType: string;
+  relationName?: string;
+  alias?: string;
+  indexName?: string;
+  startupCost: number;
+  totalCost: number;
+  exclusiveCost: number;
+  planRows: number;
+  // set only for ANALYZE, rows and times are per loop
+  actualRows?: number;
+  actualLoops?: number;
+  actualStartupTimeMs?: number;
+  actualTotalTimeMs?: number;
+  exclusiveTimeMs?: number;
+  rowsEstimateFactor?: number;
+  sharedHitBlocks: number;
+  sharedReadBlocks: number;
+  expensive: boolean;
+  misestimated: boolean;
+  details: Record<string, any>;
+  plans: PlanNode[];
+}
+
+export interface SQLExplainResponse {
+  query: string;
+  args: any[];
+  plan: PlanNode;
+  planningTimeMs?: number;
+  executionTimeMs?: number;
+  raw: any;
+  // plan of the total rows count query of the table view
+  count?: SQLExplainResponse;
+}
+
+export interface SQLExplainRequest {
+  query?: string;
+  args?: any[];
+  // runs the query in a transaction that is always rolled back
+  analyze?: boolean;
+  // explain rows query of the table, params are in the query string format
+  table?: string;
+  params?: string;
+  tableView?: boolean;
+  queryId?: string;
+  timeoutMs?: number;
+}
+
+export async function explainSQL(req: SQLExplainRequest) {
+  return fetchApiwithAuth<SQLExplainResponse>("/api/sql/explain", {
+    method: "POST",
+    body: JSON.stringify(req),
+  });
+}
+
+export async function cancelSQL(queryId: string) {
+  const { error } = await fetchApiwithAuth(`/api/sql/cancel/${encodeURIComponent(queryId)}`, {
+    method: "POST",
+  });
+
+  return { error };
+}
+
+export type SavedQueryParamType = "text" | "integer" | "numeric" | "boolean" | "date" | "timestamp" | "json";
+
+export interface SavedQueryParam {
+  name: string;
+  type: SavedQueryParamType;
+  // param without default is required
+  default?: any;
+}
+
+export interface SavedQuery {
+  id: number;
+  name: string;
+  description: string;
+  query: string;
+  // the first param is $1
+  params: SavedQueryParam[];
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export type SavedQueryInput = Pick<SavedQuery, "name" | "description" | "query" | "params">;
+
+export async function getSavedQueries() {
+  return fetchApiwithAuth<SavedQuery[]>("/api/sql/saved");
+}
+
+export async function getSavedQuery(id: number) {
+  return fetchApiwithAuth<SavedQuery>(`/api/sql/saved/${id}`);
+}
+
+export async function createSavedQuery(q: SavedQueryInput) {
+  return fetchApiwithAuth<SavedQuery>("/api/sql/saved", {
+    method: "POST",
+    body: JSON.stringify(q),
+  });
+}
+
+export async function updateSavedQuery(id: number, q: SavedQueryInput) {
+  return fetchApiwithAuth<SavedQuery>(`/api/sql/saved/${id}`, {
+    method: "PUT",
+    body: JSON.stringify(q),
+  });
+}
+
+export async function deleteSavedQuery(id: number) {
+  const { error } = await fetchApiwithAuth(`/api/sql/saved/${id}`, {
+    method: "DELETE",
+  });
+
+  return { error };
+}
+
+export async function runSavedQuery(
+  id: number,
+  params: Record<string, any> = {},
+  options: SQLExecutionOptions = {},
+) {
+  return fetchApiwithAuth<SQLExecutionResponse>(`/api/sql/saved/${id}/run`, {
+    method: "POST",
+    body: JSON.stringify({ params, ...options }),
+  });
 }
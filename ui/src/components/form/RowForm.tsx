@@ -3,7 +3,7 @@ import { DynamicInput } from "@/components/form/DynamicInput";
 import { Button } from "@/components/ui/button";
 import { alert } from "@/components/ui/global-alert";
 import { Label } from "@/components/ui/label";
-import { useTables } from "@/hooks/use-tables";
+import { findTable, useTables } from "@/hooks/use-tables";
 import { DataRow } from "@/lib/dataRow";
 import { PgTable } from "@/lib/pgTypes";
 import { generateEditRelationsLink, TableSettings } from "@/lib/tableSettings";
@@ -35,7 +35,7 @@ export function RowForm({
   const update = async () => {
     if (!row) return;
 
-    const { error, rows } = await updateTableRowByPKeys(table.name, row.pKeys(), updatedRow);
+    const { error, rows } = await updateTableRowByPKeys(table.key, row.pKeys(), updatedRow);
 
     if (error) {
       alert.error(error.message);
@@ -49,7 +49,7 @@ export function RowForm({
   };
 
   const insert = async () => {
-    const { error, rows } = await insertTableRow(table.name, updatedRow);
+    const { error, rows } = await insertTableRow(table.key, updatedRow);
 
     if (error) {
       alert.error(error.message);
@@ -74,7 +74,7 @@ export function RowForm({
   const allTables = useTables();
 
   const getTable = (tableName: string) => {
-    const res = allTables.find((t) => t.name === tableName);
+    const res = findTable(allTables, tableName);
 
     if (!res) throw Error(`Can't getTable = ${tableName}`);
 
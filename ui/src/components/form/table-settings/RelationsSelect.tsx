@@ -66,8 +66,8 @@ export function RelationsSelect({ mainTable, relations = [], onChange }: Relatio
               <SelectLabel>Tables</SelectLabel>
               {tables.map((t) => {
                 return (
-                  <SelectItem key={t.name} value={t.name}>
-                    {t.name}
+                  <SelectItem key={t.key} value={t.key}>
+                    {t.key}
                   </SelectItem>
                 );
               })}
@@ -84,8 +84,8 @@ export function RelationsSelect({ mainTable, relations = [], onChange }: Relatio
               <SelectLabel>Tables</SelectLabel>
               {tables.map((t) => {
                 return (
-                  <SelectItem key={t.name} value={t.name}>
-                    {t.name}
+                  <SelectItem key={t.key} value={t.key}>
+                    {t.key}
                   </SelectItem>
                 );
               })}
@@ -42,7 +42,7 @@ export function TableSettingsForm({
   const [setttings, setSettings] = useState(initSettngs);
 
   const saveChanges = async () => {
-    const { error } = await updateTableSettings(table.name, setttings);
+    const { error } = await updateTableSettings(table.key, setttings);
 
     if (error) {
       alert.error(error.message);
@@ -108,7 +108,7 @@ export function TableSettingsForm({
         <Label>Relations</Label>
         <RelationsSelect
           relations={setttings.relations}
-          mainTable={table.name}
+          mainTable={table.key}
           onChange={(relations) => {
             setSettings({
               ...setttings,
@@ -56,7 +56,7 @@ export function AdminRoot() {
     });
   };
 
-  const menuTables = isTablesReloading ? [] : tables.map((t) => t.name);
+  const menuTables = isTablesReloading ? [] : tables.map((t) => t.key);
 
   return (
     <SidebarProvider>
@@ -14,7 +14,7 @@ export function DatabaseStatsCard({ stats }: DatabaseStatsCardProps) {
         </div>
         <div>
           <span>schema: </span>
-          <span className="font-semibold">{stats.schemaName}</span>
+          <span className="font-semibold">{(stats.schemaNames || [stats.schemaName]).join(", ")}</span>
         </div>
       </div>
 
@@ -25,7 +25,7 @@ export function TableViewManager({
   rows,
   rowsErrorMessage,
 }: TableViewManagerProps) {
-  const tableName = table.name;
+  const tableName = table.key;
 
   const navigate = useNavigate();
 
@@ -7,10 +7,15 @@ export function useTables() {
   return useContext(TablesContext);
 }
 
+// accepts schema qualified key or plain table name
+export function findTable(tables: PgTable[], name: string) {
+  return tables.find((t) => t.key === name) || tables.find((t) => t.name === name);
+}
+
 export function useTable(name: string) {
   const tables = useTables();
 
-  const table = tables.find((t) => t.name === name);
+  const table = findTable(tables, name);
 
   if (!table) {
     throw Error("Unknown table");
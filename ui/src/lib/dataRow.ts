@@ -1,3 +1,4 @@
+import { pkeysMapToWhere } from "@/api/data";
 import { fieldToString, PgTable, Row, RowPkeysMap } from "@/lib/pgTypes";
 import { generateViewLink } from "@/lib/tableSettings";
 
@@ -66,27 +67,19 @@ export class DataRow {
   }
 
   pKeysFilters() {
-    const pkeys = this.pKeys();
+    const where = JSON.stringify(pkeysMapToWhere(this.pKeys()));
 
-    const filters = Object.entries(pkeys)
-      .map((e) => {
-        const [pkey, val] = e;
-
-        return `${pkey}=${val}`;
-      })
-      .join(" AND ");
-
-    return { filters };
+    return { where };
   }
 
   updateLink() {
     const s = new URLSearchParams(this.pKeysFilters());
-    return `/${this.#table.name}/row/update?${s}`;
+    return `/${this.#table.key}/row/update?${s}`;
   }
 
   uniqueKey() {
     const pk = this.pKeys();
-    const tableName = this.#table.name;
+    const tableName = this.#table.key;
     return `${tableName}-${Object.values(pk).join("-")}`;
   }
 
@@ -126,14 +126,18 @@ export interface PgColumn {
 }
 
 interface PgForeignKeyInfo {
+  tableSchema: string;
   tableName: string;
   columnName: string;
   constraintName: string;
 }
 
 export interface PgTable {
+  // schema qualified name (schema.table)
+  key: string;
   name: string;
   schema: string;
+  kind: "table" | "view" | "materializedView";
   columns: PgColumn[];
 }
 
@@ -1,6 +1,7 @@
 export interface DatabaseSchemaStats {
   dbName: string;
   schemaName: string;
+  schemaNames: string[];
   tablesCount: number;
   totalRows: number;
   size: number;
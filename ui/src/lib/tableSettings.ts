@@ -8,6 +8,8 @@ export interface TableSettings {
   tableViewTextFiltersCols: string[];
   overriddenInputs?: OverriddenInputsMap;
   relations?: RelationsConfig[];
+  softDeleteColumn?: string;
+  softDeleteValue?: string;
 }
 
 export interface RelationsConfig {
@@ -64,7 +66,10 @@ export function generateEditRelationsLink(
 
 function getForeignKeyColumnByTable(joinTable: PgTable, foreignTableName: string) {
   const fkCol = joinTable.columns.find(
-    (col) => col.foreignKey && col.foreignKey.tableName === foreignTableName,
+    (col) =>
+      col.foreignKey &&
+      (`${col.foreignKey.tableSchema}.${col.foreignKey.tableName}` === foreignTableName ||
+        col.foreignKey.tableName === foreignTableName),
   );
 
   if (!(fkCol && fkCol.foreignKey)) return undefined;
@@ -34,7 +34,7 @@ async function getMainTableRow(tableName: string, idKey: string, idVal: any) {
   const params: GetTableRowsParams = {
     offset: 0,
     limit: 1,
-    filters: `${idKey}=${idVal}`,
+    where: JSON.stringify({ col: idKey, op: "eq", value: idVal }),
   };
 
   const { rows, error } = await getTableRows(tableName, params);
@@ -9,9 +9,9 @@ import { data, LoaderFunctionArgs, useLoaderData, useNavigate, useRevalidator }
 export async function loader({ params, request }: LoaderFunctionArgs) {
   const tableName = params.tableName || "";
   const mode: FormViewMode = params.mode === "update" ? "update" : "insert";
-  const pKeysFilters = parseQueryRowsParams(new URL(request.url)).filters;
+  const pKeysWhere = parseQueryRowsParams(new URL(request.url)).where;
 
-  const { tableSettings, row: rawRow, error } = await getFormView(tableName, mode, pKeysFilters);
+  const { tableSettings, row: rawRow, error } = await getFormView(tableName, mode, pKeysWhere);
 
   if (error) {
     throw data(error.message, { status: error.code });
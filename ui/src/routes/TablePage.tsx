@@ -30,7 +30,7 @@ export function TablePage() {
   // after we user goes to a different table
   return (
     <TableViewManager
-      key={table.name}
+      key={table.key}
       table={table}
       selectColumns={columns}
       rows={rows}
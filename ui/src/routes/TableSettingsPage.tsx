@@ -40,7 +40,7 @@ export function TableSettingsPage() {
         <h1 className="scroll-m-20 pb-2 text-2xl font-semibold">{tableName} settings</h1>
       </div>
       <TableSettingsForm
-        key={table.name}
+        key={table.key}
         table={table}
         setttings={tableSettings}
         onCancel={goBack}